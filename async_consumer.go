@@ -121,11 +121,15 @@ func (r *AsyncConsumer) handleLazy(event Event) {
 	ids := r.handleQueries(r.engine, data)
 	r.handleClearCache(data, "cl", ids)
 	r.handleClearCache(data, "cr", ids)
+	r.handleUpserts(r.engine, data)
 	event.Ack()
 }
 
 func (r *AsyncConsumer) handleQueries(engine *Engine, validMap map[string]interface{}) []uint64 {
-	queries := validMap["q"]
+	queries, has := validMap["q"]
+	if !has {
+		return nil
+	}
 	validQueries := queries.([]interface{})
 	ids := make([]uint64, len(validQueries))
 	for i, query := range validQueries {
@@ -150,6 +154,9 @@ func (r *AsyncConsumer) handleQueries(engine *Engine, validMap map[string]interf
 			}
 			if sql[0:11] == "INSERT INTO" {
 				ids[i] = res.LastInsertId()
+				if len(validInsert) > 3 {
+					addLazyInsertToBloomFilter(engine, validInsert[3].(string), db, res)
+				}
 			} else {
 				ids[i] = 0
 			}
@@ -158,6 +165,44 @@ func (r *AsyncConsumer) handleQueries(engine *Engine, validMap map[string]interf
 	return ids
 }
 
+func (r *AsyncConsumer) handleUpserts(engine *Engine, validMap map[string]interface{}) {
+	upserts, has := validMap["u"]
+	if !has {
+		return
+	}
+	localCacheSets := make(map[string]map[string][]interface{})
+	localCacheDeletes := make(map[string]map[string]bool)
+	rFlusher := &redisFlusher{engine: engine}
+	for _, upsert := range upserts.([]interface{}) {
+		func() {
+			defer func() {
+				if rec := recover(); rec != nil {
+					if r.errorHandler != nil {
+						r.errorHandler(rec)
+					}
+				}
+			}()
+			group := newUpsertGroupFromLazy(engine, upsert.([]interface{}))
+			flushUpsert(engine, group, localCacheSets, localCacheDeletes, rFlusher, make(dataLoaderSets))
+		}()
+	}
+	for _, values := range localCacheSets {
+		for cacheCode, keys := range values {
+			engine.GetLocalCache(cacheCode).MSet(keys...)
+			publishLocalCacheSetsInvalidation(engine, cacheCode, keys)
+		}
+	}
+	for cacheCode, keys := range localCacheDeletes {
+		toRemove := make([]string, 0, len(keys))
+		for key := range keys {
+			toRemove = append(toRemove, key)
+		}
+		engine.GetLocalCache(cacheCode).Remove(toRemove...)
+		publishLocalCacheInvalidation(engine, cacheCode, toRemove...)
+	}
+	rFlusher.Flush()
+}
+
 func (r *AsyncConsumer) handleClearCache(validMap map[string]interface{}, key string, ids []uint64) {
 	keys, has := validMap[key]
 	if has {
@@ -180,6 +225,7 @@ func (r *AsyncConsumer) handleClearCache(validMap map[string]interface{}, key st
 			if key == "cl" {
 				cache := r.engine.GetLocalCache(cacheCode)
 				cache.Remove(stringKeys...)
+				publishLocalCacheInvalidation(r.engine, cacheCode, stringKeys...)
 			} else {
 				cache := r.engine.GetRedis(cacheCode)
 				cache.Del(stringKeys...)
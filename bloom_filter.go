@@ -0,0 +1,197 @@
+package orm
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+
+	"github.com/segmentio/fasthash/fnv1a"
+)
+
+const defaultBloomFilterCapacity = 1000000
+const bloomFilterFalsePositiveRate = 0.01
+const bloomFilterBuildBatchSize = 10000
+const bloomFilterBuildingSuffix = ":building"
+
+const bloomFilterCheckScript = `if redis.call('EXISTS', KEYS[1]) == 0 then
+	return -1
+end
+local k = tonumber(ARGV[1])
+local found = {}
+for i = 2, #ARGV, k do
+	local result = 1
+	for j = i, i + k - 1 do
+		if redis.call('GETBIT', KEYS[1], ARGV[j]) == 0 then
+			result = 0
+			break
+		end
+	end
+	table.insert(found, result)
+end
+return found`
+
+const bloomFilterAddScript = `for _, key in ipairs(KEYS) do
+	if redis.call('EXISTS', key) == 1 then
+		for i = 1, #ARGV do
+			redis.call('SETBIT', key, ARGV[i], 1)
+		end
+	end
+end
+return 1`
+
+const bloomFilterStartScript = `if redis.call('EXISTS', KEYS[1]) == 0 then
+	redis.call('SET', KEYS[1], '')
+end
+return 1`
+
+const bloomFilterFinishScript = `redis.call('SET', KEYS[3], ARGV[1])
+redis.call('BITOP', 'OR', KEYS[1], KEYS[1], KEYS[2], KEYS[3])
+redis.call('DEL', KEYS[2], KEYS[3])
+return 1`
+
+type bloomFilter struct {
+	size      uint64
+	hashes    int
+	redisPool string
+	key       string
+}
+
+func parseBloomFilter(tags map[string]map[string]string, redisCache string, entityType reflect.Type) (*bloomFilter, error) {
+	userValue, has := tags["ORM"]["bloomFilter"]
+	if !has {
+		return nil, nil
+	}
+	if redisCache == "" {
+		return nil, fmt.Errorf("bloomFilter in %s requires redis cache", entityType.String())
+	}
+	capacity := defaultBloomFilterCapacity
+	if userValue != "true" {
+		parsed, err := strconv.Atoi(userValue)
+		if err != nil || parsed <= 0 {
+			return nil, fmt.Errorf("invalid bloomFilter '%s' in %s", userValue, entityType.String())
+		}
+		capacity = parsed
+	}
+	size := math.Ceil(-float64(capacity) * math.Log(bloomFilterFalsePositiveRate) / (math.Ln2 * math.Ln2))
+	hashes := int(math.Round(size / float64(capacity) * math.Ln2))
+	return &bloomFilter{size: uint64(size), hashes: hashes, redisPool: redisCache}, nil
+}
+
+func (b *bloomFilter) offsets(id uint64) []uint64 {
+	hash := fnv1a.HashUint64(id)
+	h1 := hash & math.MaxUint32
+	h2 := hash>>32 | 1
+	offsets := make([]uint64, b.hashes)
+	for i := range offsets {
+		offsets[i] = (h1 + uint64(i)*h2) % b.size
+	}
+	return offsets
+}
+
+func filterByBloomFilter(engine *Engine, schema *tableSchema, ids []uint64) []uint64 {
+	b := schema.bloomFilter
+	if b == nil || len(ids) == 0 || schema.GetMysql(engine).inTransaction {
+		return ids
+	}
+	return b.filterRedis(engine, schema, ids, true)
+}
+
+func (b *bloomFilter) filterRedis(engine *Engine, schema *tableSchema, ids []uint64, build bool) []uint64 {
+	arguments := make([]interface{}, 1, len(ids)*b.hashes+1)
+	arguments[0] = b.hashes
+	for _, id := range ids {
+		for _, offset := range b.offsets(id) {
+			arguments = append(arguments, offset)
+		}
+	}
+	result := engine.GetRedis(b.redisPool).Eval(bloomFilterCheckScript, []string{b.key}, arguments...)
+	found, isList := result.([]interface{})
+	if !isList {
+		if !build {
+			return ids
+		}
+		check := func() (interface{}, bool) {
+			return nil, engine.GetRedis(b.redisPool).Exists(b.key) > 0
+		}
+		engine.registry.stampedeProtection.do(engine, b.key, check, func() interface{} {
+			b.buildRedis(engine, schema)
+			return nil
+		})
+		return b.filterRedis(engine, schema, ids, false)
+	}
+	filtered := make([]uint64, 0, len(ids))
+	for i, id := range ids {
+		if found[i].(int64) == 1 {
+			filtered = append(filtered, id)
+		}
+	}
+	return filtered
+}
+
+func (b *bloomFilter) buildRedis(engine *Engine, schema *tableSchema) {
+	redisCache := engine.GetRedis(b.redisPool)
+	building := b.key + bloomFilterBuildingSuffix
+	redisCache.Eval(bloomFilterStartScript, []string{building})
+	bitmap := buildBloomFilterBitmap(engine, schema)
+	redisCache.Eval(bloomFilterFinishScript, []string{b.key, building, b.key + ":tmp"}, string(bitmap))
+}
+
+func buildBloomFilterBitmap(engine *Engine, schema *tableSchema) []byte {
+	b := schema.bloomFilter
+	bitmap := make([]byte, (b.size+7)/8)
+	lastID := uint64(0)
+	for {
+		/* #nosec */
+		query := "SELECT `ID` FROM `" + schema.tableName + "` WHERE `ID` > ? ORDER BY `ID` LIMIT " + strconv.Itoa(bloomFilterBuildBatchSize)
+		results, def := schema.GetMysql(engine).Query(query, lastID)
+		rows := 0
+		for results.Next() {
+			results.Scan(&lastID)
+			for _, offset := range b.offsets(lastID) {
+				bitmap[offset/8] |= 0x80 >> (offset % 8)
+			}
+			rows++
+		}
+		def()
+		if rows < bloomFilterBuildBatchSize {
+			return bitmap
+		}
+	}
+}
+
+func addToBloomFilter(schema *tableSchema, redisFlusher RedisFlusher, id uint64) {
+	b := schema.bloomFilter
+	if b == nil || id == 0 {
+		return
+	}
+	redisFlusher.setBloomFilterBits(b.redisPool, b.key, b.offsets(id)...)
+}
+
+func addLazyInsertToBloomFilter(engine *Engine, entityName string, db *DB, result ExecResult) {
+	t, has := engine.registry.entities[entityName]
+	if !has {
+		return
+	}
+	schema := getTableSchema(engine.registry, t)
+	if schema.bloomFilter == nil {
+		return
+	}
+	rFlusher := &redisFlusher{engine: engine}
+	id := result.LastInsertId()
+	for i := uint64(0); i < result.RowsAffected(); i++ {
+		addToBloomFilter(schema, rFlusher, id)
+		id += db.autoincrement
+	}
+	rFlusher.Flush()
+}
+
+func rebuildBloomFilter(engine *Engine, entity Entity) {
+	schema := initIfNeeded(engine, entity).tableSchema
+	b := schema.bloomFilter
+	if b == nil {
+		panic(fmt.Errorf("bloom filter not defined for entity '%s'", schema.t.String()))
+	}
+	engine.GetRedis(b.redisPool).Del(b.key)
+	b.buildRedis(engine, schema)
+}
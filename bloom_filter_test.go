@@ -0,0 +1,108 @@
+package orm
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type bloomFilterEntity struct {
+	ORM  `orm:"localCache;redisCache;bloomFilter=1000"`
+	ID   uint
+	Name string
+}
+
+type bloomFilterRedisEntity struct {
+	ORM  `orm:"redisCache;bloomFilter"`
+	ID   uint
+	Name string
+}
+
+type bloomFilterInvalidEntity struct {
+	ORM `orm:"redisCache;bloomFilter=abc"`
+	ID  uint
+}
+
+type bloomFilterLocalEntity struct {
+	ORM `orm:"localCache;bloomFilter"`
+	ID  uint
+}
+
+func TestBloomFilterSecondEngine(t *testing.T) {
+	var entity *bloomFilterEntity
+	registry := &Registry{}
+	engine := PrepareTables(t, registry, 5, entity)
+	schema := engine.GetRegistry().GetTableSchemaForEntity(entity).(*tableSchema)
+	assert.Equal(t, uint64(9586), schema.bloomFilter.size)
+	assert.Equal(t, 7, schema.bloomFilter.hashes)
+
+	engine.FlushMany(&bloomFilterEntity{Name: "a"}, &bloomFilterEntity{Name: "b"})
+	entity = &bloomFilterEntity{}
+	assert.True(t, engine.LoadByID(1, entity))
+
+	engine2 := engine.GetRegistry().CreateEngine()
+	engine2.Flush(&bloomFilterEntity{Name: "c"})
+	assert.True(t, engine.LoadByID(3, entity))
+	assert.Equal(t, "c", entity.Name)
+
+	receiver := NewAsyncConsumer(engine, "default-consumer")
+	receiver.DisableLoop()
+	receiver.block = time.Millisecond
+	engine2.FlushLazy(&bloomFilterEntity{Name: "d"})
+	receiver.Digest(context.Background(), 100)
+	assert.True(t, engine.LoadByID(4, entity))
+	assert.Equal(t, "d", entity.Name)
+	var rows []*bloomFilterEntity
+	missing := engine.LoadByIDs([]uint64{1, 4, 1000}, &rows)
+	assert.Len(t, rows, 2)
+	assert.Equal(t, []uint64{1000}, missing)
+}
+
+func TestBloomFilterRedis(t *testing.T) {
+	var entity *bloomFilterRedisEntity
+	engine := PrepareTables(t, &Registry{}, 5, entity)
+	schema := engine.GetRegistry().GetTableSchemaForEntity(entity).(*tableSchema)
+
+	engine.FlushMany(&bloomFilterRedisEntity{Name: "a"}, &bloomFilterRedisEntity{Name: "b"})
+	assert.Equal(t, int64(0), engine.GetRedis().Exists(schema.bloomFilter.key))
+	entity = &bloomFilterRedisEntity{}
+	assert.True(t, engine.LoadByID(2, entity))
+	assert.Equal(t, int64(1), engine.GetRedis().Exists(schema.bloomFilter.key))
+	assert.Equal(t, int64(0), engine.GetRedis().Exists(schema.bloomFilter.key+bloomFilterBuildingSuffix))
+
+	engine.ResetCacheMetrics()
+	assert.False(t, engine.LoadByID(3, entity))
+	assert.Len(t, engine.GetCacheMetrics(), 0)
+	_, has := engine.GetRedis().Get(schema.getCacheKey(3))
+	assert.False(t, has)
+
+	engine.Flush(&bloomFilterRedisEntity{Name: "c"})
+	assert.True(t, engine.LoadByID(3, entity))
+
+	engine.GetMysql().Begin()
+	engine.Flush(&bloomFilterRedisEntity{Name: "d"})
+	assert.True(t, engine.LoadByID(4, entity))
+	engine.GetMysql().Commit()
+	assert.True(t, engine.LoadByID(4, entity))
+
+	engine.GetRedis().FlushDB()
+	var rows []*bloomFilterRedisEntity
+	missing := engine.LoadByIDs([]uint64{1, 4, 10}, &rows)
+	assert.Len(t, rows, 2)
+	assert.Equal(t, []uint64{10}, missing)
+
+	registry := &Registry{}
+	registry.RegisterMySQLPool("root:root@tcp(localhost:3311)/test")
+	registry.RegisterEntity(&bloomFilterInvalidEntity{})
+	_, err := registry.Validate()
+	assert.EqualError(t, err, "invalid bloomFilter 'abc' in orm.bloomFilterInvalidEntity")
+
+	registry = &Registry{}
+	registry.RegisterMySQLPool("root:root@tcp(localhost:3311)/test")
+	registry.RegisterLocalCache(100)
+	registry.RegisterEntity(&bloomFilterLocalEntity{})
+	_, err = registry.Validate()
+	assert.EqualError(t, err, "bloomFilter in orm.bloomFilterLocalEntity requires redis cache")
+}
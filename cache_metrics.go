@@ -0,0 +1,115 @@
+package orm
+
+import (
+	"sort"
+	"sync"
+	"sync/atomic"
+)
+
+const (
+	CacheLayerRequest = "request"
+	CacheLayerLocal   = "local"
+	CacheLayerRedis   = "redis"
+	CacheLayerDB      = "db"
+)
+
+type CacheMetric struct {
+	Entity string
+	Query  string
+	Layer  string
+	Hits   uint64
+	Misses uint64
+}
+
+type cacheMetricKey struct {
+	entity string
+	query  string
+	layer  string
+}
+
+type cacheMetricCounter struct {
+	hits   uint64
+	misses uint64
+}
+
+type cacheMetrics struct {
+	mutex    sync.RWMutex
+	counters map[cacheMetricKey]*cacheMetricCounter
+}
+
+func newCacheMetrics() *cacheMetrics {
+	return &cacheMetrics{counters: make(map[cacheMetricKey]*cacheMetricCounter)}
+}
+
+func (m *cacheMetrics) add(schema *tableSchema, query string, layer string, hits int, misses int) {
+	if m == nil || hits+misses == 0 {
+		return
+	}
+	key := cacheMetricKey{entity: schema.t.String(), query: query, layer: layer}
+	m.mutex.RLock()
+	counter, has := m.counters[key]
+	m.mutex.RUnlock()
+	if !has {
+		m.mutex.Lock()
+		counter, has = m.counters[key]
+		if !has {
+			counter = &cacheMetricCounter{}
+			m.counters[key] = counter
+		}
+		m.mutex.Unlock()
+	}
+	atomic.AddUint64(&counter.hits, uint64(hits))
+	atomic.AddUint64(&counter.misses, uint64(misses))
+}
+
+func (m *cacheMetrics) get() []*CacheMetric {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	metrics := make([]*CacheMetric, 0, len(m.counters))
+	for key, counter := range m.counters {
+		metrics = append(metrics, &CacheMetric{Entity: key.entity, Query: key.query, Layer: key.layer,
+			Hits: atomic.LoadUint64(&counter.hits), Misses: atomic.LoadUint64(&counter.misses)})
+	}
+	sort.Slice(metrics, func(i, j int) bool {
+		if metrics[i].Entity != metrics[j].Entity {
+			return metrics[i].Entity < metrics[j].Entity
+		}
+		if metrics[i].Query != metrics[j].Query {
+			return metrics[i].Query < metrics[j].Query
+		}
+		return cacheLayerOrder(metrics[i].Layer) < cacheLayerOrder(metrics[j].Layer)
+	})
+	return metrics
+}
+
+func (m *cacheMetrics) reset() {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.counters = make(map[cacheMetricKey]*cacheMetricCounter)
+}
+
+func localCacheLayer(localCache *LocalCache) string {
+	if localCache.code == requestCacheKey {
+		return CacheLayerRequest
+	}
+	return CacheLayerLocal
+}
+
+func cacheLayerOrder(layer string) int {
+	switch layer {
+	case CacheLayerRequest:
+		return 0
+	case CacheLayerLocal:
+		return 1
+	case CacheLayerRedis:
+		return 2
+	}
+	return 3
+}
+
+func boolToInt(value bool) int {
+	if value {
+		return 1
+	}
+	return 0
+}
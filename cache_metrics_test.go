@@ -0,0 +1,85 @@
+package orm
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type cacheMetricsEntity struct {
+	ORM       `orm:"localCache;redisCache"`
+	ID        uint
+	Name      string       `orm:"length=100;index=Name"`
+	IndexName *CachedQuery `query:":Name = ?"`
+}
+
+func TestCacheMetrics(t *testing.T) {
+	var entity *cacheMetricsEntity
+	engine := PrepareTables(t, &Registry{}, 5, entity)
+	engine.Flush(&cacheMetricsEntity{Name: "a"})
+	engine.GetLocalCache().Clear()
+	engine.GetRedis().FlushDB()
+	engine.ResetCacheMetrics()
+	assert.Len(t, engine.GetCacheMetrics(), 0)
+
+	assert.True(t, engine.LoadByID(1, &cacheMetricsEntity{}))
+	assert.True(t, engine.LoadByID(1, &cacheMetricsEntity{}))
+	assert.False(t, engine.LoadByID(2, &cacheMetricsEntity{}))
+	var rows []*cacheMetricsEntity
+	engine.LoadByIDs([]uint64{1, 3}, &rows)
+	engine.CachedSearch(&rows, "IndexName", NewPager(1, 100), "a")
+
+	metrics := engine.GetCacheMetrics()
+	assert.Len(t, metrics, 6)
+	name := "orm.cacheMetricsEntity"
+	assert.Equal(t, &CacheMetric{Entity: name, Layer: CacheLayerLocal, Hits: 3, Misses: 3}, metrics[0])
+	assert.Equal(t, &CacheMetric{Entity: name, Layer: CacheLayerRedis, Misses: 3}, metrics[1])
+	assert.Equal(t, &CacheMetric{Entity: name, Layer: CacheLayerDB, Hits: 1, Misses: 2}, metrics[2])
+	assert.Equal(t, &CacheMetric{Entity: name, Query: "IndexName", Layer: CacheLayerLocal, Misses: 1}, metrics[3])
+	assert.Equal(t, &CacheMetric{Entity: name, Query: "IndexName", Layer: CacheLayerRedis, Misses: 1}, metrics[4])
+	assert.Equal(t, &CacheMetric{Entity: name, Query: "IndexName", Layer: CacheLayerDB, Hits: 1}, metrics[5])
+
+	engine.ResetCacheMetrics()
+	assert.Len(t, engine.GetCacheMetrics(), 0)
+}
+
+type cacheMetricsRefEntity struct {
+	ORM  `orm:"localCache"`
+	ID   uint
+	Name string
+}
+
+type cacheMetricsParentEntity struct {
+	ORM  `orm:"localCache"`
+	ID   uint
+	Name string
+	Ref  *cacheMetricsRefEntity
+}
+
+func TestCacheMetricsReferencesNil(t *testing.T) {
+	var entity *cacheMetricsParentEntity
+	var ref *cacheMetricsRefEntity
+	engine := PrepareTables(t, &Registry{}, 5, ref, entity)
+	engine.FlushMany(&cacheMetricsParentEntity{Name: "a", Ref: &cacheMetricsRefEntity{Name: "a"}},
+		&cacheMetricsParentEntity{Name: "b", Ref: &cacheMetricsRefEntity{Name: "b"}})
+	refSchema := engine.GetRegistry().GetTableSchemaForEntity(ref).(*tableSchema)
+	engine.GetLocalCache().Set(refSchema.getCacheKey(1), "nil")
+	engine.GetLocalCache().Set(refSchema.getCacheKey(2), "nil")
+	engine.ResetCacheMetrics()
+
+	parent := &cacheMetricsParentEntity{}
+	assert.True(t, engine.LoadByID(1, parent, "Ref"))
+	assert.False(t, parent.Ref.Loaded())
+	metrics := engine.GetCacheMetrics()
+	assert.Len(t, metrics, 2)
+	assert.Equal(t, &CacheMetric{Entity: "orm.cacheMetricsRefEntity", Layer: CacheLayerLocal, Hits: 1}, metrics[1])
+
+	engine.ResetCacheMetrics()
+	var rows []*cacheMetricsParentEntity
+	engine.LoadByIDs([]uint64{1, 2}, &rows, "Ref")
+	assert.Len(t, rows, 2)
+	assert.False(t, rows[0].Ref.Loaded())
+	metrics = engine.GetCacheMetrics()
+	assert.Len(t, metrics, 2)
+	assert.Equal(t, &CacheMetric{Entity: "orm.cacheMetricsRefEntity", Layer: CacheLayerLocal, Hits: 2}, metrics[1])
+}
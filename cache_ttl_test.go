@@ -0,0 +1,120 @@
+package orm
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type cacheTTLEntity struct {
+	ORM             `orm:"localCache;redisCache;localCacheTTL=200ms;redisCacheTTL=1h"`
+	ID              uint
+	Name            string       `orm:"length=100;index=Name"`
+	IndexName       *CachedQuery `query:":Name = ?"`
+	IndexNameCached *CachedQuery `queryOne:":Name = ?"`
+}
+
+type cacheTTLClusterEntity struct {
+	ORM  `orm:"localCache;redisCache=cluster;localCacheTTL=200ms"`
+	ID   uint
+	Name string
+}
+
+type cacheTTLInvalidEntity struct {
+	ORM  `orm:"localCache;localCacheTTL=abc"`
+	ID   uint
+	Name string
+}
+
+type cacheTTLNoPoolEntity struct {
+	ORM  `orm:"redisCacheTTL=1h"`
+	ID   uint
+	Name string
+}
+
+func TestCacheTTL(t *testing.T) {
+	var entity *cacheTTLEntity
+	engine := PrepareTables(t, &Registry{}, 5, entity)
+	engine.Flush(&cacheTTLEntity{Name: "a"})
+	schema := engine.GetRegistry().GetTableSchemaForEntity(entity).(*tableSchema)
+	localCache := engine.GetLocalCache()
+	redisCache := engine.GetRedis()
+	localCache.Clear()
+	redisCache.FlushDB()
+
+	entity = &cacheTTLEntity{}
+	assert.True(t, engine.LoadByID(1, entity))
+	assert.False(t, engine.LoadByID(2, &cacheTTLEntity{}))
+	for _, key := range []string{schema.getCacheKey(1), schema.getCacheKey(2)} {
+		_, has := localCache.Get(key)
+		assert.True(t, has)
+		ttl := redisCache.client.TTL(context.Background(), key).Val()
+		assert.Greater(t, int64(ttl), int64(59*time.Minute))
+		assert.LessOrEqual(t, int64(ttl), int64(time.Hour))
+	}
+
+	var rows []*cacheTTLEntity
+	total := engine.CachedSearch(&rows, "IndexName", nil, "a")
+	assert.Equal(t, 1, total)
+	assert.True(t, engine.CachedSearchOne(&cacheTTLEntity{}, "IndexNameCached", "a"))
+	searchKeys := []string{getCacheKeySearch(schema, "IndexName", "a"), getCacheKeySearch(schema, "IndexNameCached", "a")}
+	for _, key := range searchKeys {
+		ttl := redisCache.client.TTL(context.Background(), key).Val()
+		assert.Greater(t, int64(ttl), int64(59*time.Minute))
+	}
+
+	rows = nil
+	engine.LoadByIDs([]uint64{1, 3}, &rows)
+	for _, key := range []string{schema.getCacheKey(1), schema.getCacheKey(3)} {
+		ttl := redisCache.client.TTL(context.Background(), key).Val()
+		assert.Greater(t, int64(ttl), int64(59*time.Minute))
+	}
+
+	time.Sleep(time.Millisecond * 250)
+	_, has := localCache.Get(schema.getCacheKey(1))
+	assert.False(t, has)
+	values := localCache.HMget(searchKeys[0], "1")
+	assert.Nil(t, values["1"])
+	localCache.Set("not-orm-key", "value")
+	_, has = localCache.Get("not-orm-key")
+	assert.True(t, has)
+
+	registry := &Registry{}
+	registry.RegisterMySQLPool("root:root@tcp(localhost:3311)/test")
+	registry.RegisterLocalCache(100)
+	registry.RegisterEntity(&cacheTTLInvalidEntity{})
+	_, err := registry.Validate()
+	assert.EqualError(t, err, "invalid localCacheTTL 'abc' in orm.cacheTTLInvalidEntity")
+
+	registry = &Registry{}
+	registry.RegisterMySQLPool("root:root@tcp(localhost:3311)/test")
+	registry.RegisterEntity(&cacheTTLNoPoolEntity{})
+	_, err = registry.Validate()
+	assert.EqualError(t, err, "redisCacheTTL defined in orm.cacheTTLNoPoolEntity without cache pool")
+}
+
+func TestCacheTTLCluster(t *testing.T) {
+	registry := &Registry{}
+	registry.RegisterMySQLPool("root:root@tcp(localhost:3311)/test")
+	registry.RegisterLocalCache(100)
+	registry.RegisterRedisCluster([]string{"localhost:7001", "localhost:7002"}, "cluster")
+	registry.RegisterEntity(&cacheTTLClusterEntity{})
+	validatedRegistry, err := registry.Validate()
+	assert.Nil(t, err)
+	schema := validatedRegistry.GetTableSchemaForEntity(&cacheTTLClusterEntity{}).(*tableSchema)
+	assert.Regexp(t, `^\{[a-f0-9]{5}\}$`, schema.cachePrefix)
+
+	config := registry.localCacheContainers["default"]
+	ttl, has := config.keyTTL(schema.getCacheKey(1))
+	assert.True(t, has)
+	assert.Equal(t, 200*time.Millisecond, ttl)
+	ttl, has = config.keyTTL(getCacheKeySearch(schema, "IndexName", "a"))
+	assert.True(t, has)
+	assert.Equal(t, 200*time.Millisecond, ttl)
+	_, has = config.keyTTL("not-orm-key")
+	assert.False(t, has)
+	_, has = config.keyTTL(schema.cachePrefix[1:6] + ":1")
+	assert.False(t, has)
+}
@@ -0,0 +1,211 @@
+package orm
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const cachedAggregatePendingSuffix = ":pending"
+const cachedAggregatePendingTTL = 60000
+
+const cachedAggregateIncrementScript = `for i = 1, #KEYS, 2 do
+	if redis.call('EXISTS', KEYS[i]) == 1 then
+		redis.call('INCRBYFLOAT', KEYS[i], ARGV[(i + 1) / 2])
+	elseif redis.call('EXISTS', KEYS[i + 1]) == 1 then
+		redis.call('INCR', KEYS[i + 1])
+	end
+end
+return 1`
+
+const cachedAggregateStartScript = `redis.call('SET', KEYS[1], 0, 'PX', ARGV[1], 'NX')
+return redis.call('GET', KEYS[1])`
+
+const cachedAggregateSetScript = `if redis.call('GET', KEYS[2]) ~= ARGV[1] or redis.call('EXISTS', KEYS[1]) == 1 then
+	return 0
+end
+if tonumber(ARGV[3]) > 0 then
+	redis.call('SET', KEYS[1], ARGV[2], 'EX', ARGV[3])
+else
+	redis.call('SET', KEYS[1], ARGV[2])
+end
+return 1`
+
+type cachedAggregateDefinition struct {
+	Query       string
+	QueryFields []string
+	SumField    string
+}
+
+func parseCachedAggregate(key string, values map[string]string, hasFakeDelete bool, redisCache string,
+	entityType reflect.Type) (*cachedAggregateDefinition, error) {
+	tag := "cachedCount"
+	query, has := values[tag]
+	if !has {
+		tag = "cachedSum"
+		query, has = values[tag]
+		if !has {
+			return nil, nil
+		}
+	}
+	if redisCache == "" {
+		return nil, fmt.Errorf("%s %s in %s requires redis cache", tag, key, entityType.String())
+	}
+	inArguments, rangeArguments, _, _ := parseCachedQueryArguments(query)
+	if len(inArguments) > 0 || len(rangeArguments) > 0 || strings.Contains(strings.ToLower(query), "order by") {
+		return nil, fmt.Errorf("%s %s in %s supports only equal conditions", tag, key, entityType.String())
+	}
+	definition := &cachedAggregateDefinition{QueryFields: make([]string, 0)}
+	re := regexp.MustCompile(":([A-Za-z0-9])+")
+	for _, variable := range re.FindAllString(query, -1) {
+		fieldName := variable[1:]
+		_, has = entityType.FieldByName(fieldName)
+		if !has {
+			return nil, fmt.Errorf("unknown field %s in %s %s in %s", fieldName, tag, key, entityType.String())
+		}
+		definition.QueryFields = append(definition.QueryFields, fieldName)
+		query = strings.Replace(query, variable, fmt.Sprintf("`%s`", fieldName), 1)
+	}
+	if query == "" {
+		query = "1"
+	}
+	if hasFakeDelete {
+		query = "`FakeDelete` = 0 AND " + query
+	}
+	definition.Query = query
+	if tag == "cachedSum" {
+		definition.SumField = values["sum"]
+		field, has := entityType.FieldByName(definition.SumField)
+		if !has || definition.SumField == "" {
+			return nil, fmt.Errorf("missing sum field for %s %s in %s", tag, key, entityType.String())
+		}
+		kind := field.Type.Kind()
+		if kind == reflect.Ptr {
+			kind = field.Type.Elem().Kind()
+		}
+		if kind < reflect.Int || kind > reflect.Float64 {
+			return nil, fmt.Errorf("sum field %s for %s %s in %s is not a number", definition.SumField, tag, key, entityType.String())
+		}
+	}
+	return definition, nil
+}
+
+func cachedAggregate(engine *Engine, entity Entity, indexName string, arguments []interface{}, sum bool) float64 {
+	schema := initIfNeeded(engine, entity).tableSchema
+	definition, has := schema.cachedAggregates[indexName]
+	if !has || (definition.SumField != "") != sum {
+		panic(fmt.Errorf("index %s not found", indexName))
+	}
+	redisCache, _ := schema.GetRedisCache(engine)
+	where := NewWhere(definition.Query, arguments...)
+	cacheKey := getCacheKeySearch(schema, indexName, where.GetParameters()...)
+	value, has := redisCache.Get(cacheKey)
+	engine.registry.cacheMetrics.add(schema, indexName, CacheLayerRedis, boolToInt(has), boolToInt(!has))
+	if has {
+		result, _ := strconv.ParseFloat(value, 64)
+		return result
+	}
+	engine.registry.cacheMetrics.add(schema, indexName, CacheLayerDB, 1, 0)
+	pendingKey := cacheKey + cachedAggregatePendingSuffix
+	version := ""
+	compute := func() interface{} {
+		/* #nosec */
+		query := "SELECT COUNT(1) FROM `" + schema.tableName + "` WHERE " + where.String()
+		if sum {
+			query = "SELECT IFNULL(SUM(`" + definition.SumField + "`), 0) FROM `" + schema.tableName + "` WHERE " + where.String()
+		}
+		var result float64
+		schema.GetMysql(engine).QueryRow(NewWhere(query, where.GetParameters()...), &result)
+		return result
+	}
+	if schema.GetMysql(engine).inTransaction {
+		return compute().(float64)
+	}
+	check := func() (interface{}, bool) {
+		value, has := redisCache.Get(cacheKey)
+		if !has {
+			return nil, false
+		}
+		result, _ := strconv.ParseFloat(value, 64)
+		return result, true
+	}
+	result, fresh := engine.registry.stampedeProtection.do(engine, cacheKey, check, func() interface{} {
+		version = redisCache.Eval(cachedAggregateStartScript, []string{pendingKey}, cachedAggregatePendingTTL).(string)
+		return compute()
+	})
+	if fresh {
+		redisCache.Eval(cachedAggregateSetScript, []string{cacheKey, pendingKey}, version,
+			strconv.FormatFloat(result.(float64), 'f', -1, 64), int(schema.redisCacheTTL/time.Second))
+	}
+	return result.(float64)
+}
+
+func addCachedAggregatesDeltas(schema *tableSchema, redisFlusher RedisFlusher, bind map[string]interface{}, old []interface{},
+	data []interface{}) {
+	if len(schema.cachedAggregates) == 0 {
+		return
+	}
+	_, fakeDeleteChanged := bind["FakeDelete"]
+	deltas := make(map[string]float64)
+	for indexName, definition := range schema.cachedAggregates {
+		if old != nil && data != nil && !fakeDeleteChanged {
+			changed := false
+			for _, field := range definition.QueryFields {
+				_, has := bind[field]
+				changed = changed || has
+			}
+			_, has := bind[definition.SumField]
+			if !changed && !has {
+				continue
+			}
+		}
+		if isCachedAggregateMember(schema, old) {
+			deltas[getCachedAggregateKey(schema, indexName, definition, old)] -= getCachedAggregateValue(schema, definition, old)
+		}
+		if isCachedAggregateMember(schema, data) {
+			deltas[getCachedAggregateKey(schema, indexName, definition, data)] += getCachedAggregateValue(schema, definition, data)
+		}
+	}
+	for key, delta := range deltas {
+		if delta != 0 {
+			redisFlusher.incrementIfExists(schema.redisCacheName, key, delta)
+		}
+	}
+}
+
+func isCachedAggregateMember(schema *tableSchema, data []interface{}) bool {
+	if data == nil {
+		return false
+	}
+	if schema.hasFakeDelete {
+		fakeDelete := data[schema.columnMapping["FakeDelete"]]
+		return fakeDelete == nil || fakeDelete == uint64(0)
+	}
+	return true
+}
+
+func getCachedAggregateKey(schema *tableSchema, indexName string, definition *cachedAggregateDefinition, data []interface{}) string {
+	attributes := make([]interface{}, len(definition.QueryFields))
+	for i, field := range definition.QueryFields {
+		attributes[i] = data[schema.columnMapping[field]]
+	}
+	return getCacheKeySearch(schema, indexName, attributes...)
+}
+
+func getCachedAggregateValue(schema *tableSchema, definition *cachedAggregateDefinition, data []interface{}) float64 {
+	if definition.SumField == "" {
+		return 1
+	}
+	switch v := data[schema.columnMapping[definition.SumField]].(type) {
+	case uint64:
+		return float64(v)
+	case int64:
+		return float64(v)
+	case float64:
+		return v
+	}
+	return 0
+}
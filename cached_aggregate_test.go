@@ -0,0 +1,90 @@
+package orm
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type cachedAggregateEntity struct {
+	ORM         `orm:"redisCache"`
+	ID          uint
+	UserID      uint
+	Amount      float64
+	FakeDelete  bool
+	CountByUser *CachedQuery `cachedCount:":UserID = ?"`
+	SumByUser   *CachedQuery `cachedSum:":UserID = ?" orm:"sum=Amount"`
+	CountAll    *CachedQuery `cachedCount:""`
+}
+
+type cachedAggregateInvalidEntity struct {
+	ORM
+	ID          uint
+	CountByUser *CachedQuery `cachedCount:":ID = ?"`
+}
+
+func TestCachedAggregate(t *testing.T) {
+	var entity *cachedAggregateEntity
+	engine := PrepareTables(t, &Registry{}, 5, entity)
+	engine.FlushMany(&cachedAggregateEntity{UserID: 1, Amount: 10}, &cachedAggregateEntity{UserID: 1, Amount: 2.5},
+		&cachedAggregateEntity{UserID: 2, Amount: 7})
+
+	assert.Equal(t, 2, engine.CachedCount(&cachedAggregateEntity{}, "CountByUser", 1))
+	assert.Equal(t, 12.5, engine.CachedSum(&cachedAggregateEntity{}, "SumByUser", 1))
+	assert.Equal(t, 3, engine.CachedCount(&cachedAggregateEntity{}, "CountAll"))
+	assert.Equal(t, 0, engine.CachedCount(&cachedAggregateEntity{}, "CountByUser", 3))
+
+	engine.ResetCacheMetrics()
+	engine.Flush(&cachedAggregateEntity{UserID: 1, Amount: 1})
+	assert.Equal(t, 3, engine.CachedCount(&cachedAggregateEntity{}, "CountByUser", 1))
+	assert.Equal(t, 13.5, engine.CachedSum(&cachedAggregateEntity{}, "SumByUser", 1))
+	assert.Equal(t, 4, engine.CachedCount(&cachedAggregateEntity{}, "CountAll"))
+	for _, metric := range engine.GetCacheMetrics() {
+		assert.NotEqual(t, CacheLayerDB, metric.Layer)
+	}
+
+	entity = &cachedAggregateEntity{}
+	engine.LoadByID(1, entity)
+	entity.UserID = 3
+	entity.Amount = 20
+	engine.Flush(entity)
+	assert.Equal(t, 2, engine.CachedCount(&cachedAggregateEntity{}, "CountByUser", 1))
+	assert.Equal(t, 3.5, engine.CachedSum(&cachedAggregateEntity{}, "SumByUser", 1))
+	assert.Equal(t, 20.0, engine.CachedSum(&cachedAggregateEntity{}, "SumByUser", 3))
+
+	engine.UpdateFields(entity, 1, Bind{"Amount": 5.0})
+	assert.Equal(t, 5.0, engine.CachedSum(&cachedAggregateEntity{}, "SumByUser", 3))
+
+	engine.LoadByID(1, entity)
+	engine.Delete(entity)
+	assert.Equal(t, 0, engine.CachedCount(&cachedAggregateEntity{}, "CountByUser", 3))
+	assert.Equal(t, 0.0, engine.CachedSum(&cachedAggregateEntity{}, "SumByUser", 3))
+	assert.Equal(t, 3, engine.CachedCount(&cachedAggregateEntity{}, "CountAll"))
+
+	engine.GetRedis().FlushDB()
+	assert.Equal(t, 2, engine.CachedCount(&cachedAggregateEntity{}, "CountByUser", 1))
+	assert.Equal(t, 3, engine.CachedCount(&cachedAggregateEntity{}, "CountAll"))
+
+	schema := engine.GetRegistry().GetTableSchemaForEntity(entity).(*tableSchema)
+	cacheKey := getCacheKeySearch(schema, "CountByUser", 5)
+	pendingKey := cacheKey + cachedAggregatePendingSuffix
+	version := engine.GetRedis().Eval(cachedAggregateStartScript, []string{pendingKey}, cachedAggregatePendingTTL)
+	assert.Equal(t, "0", version)
+	engine.Flush(&cachedAggregateEntity{UserID: 5, Amount: 1})
+	assert.Equal(t, int64(0), engine.GetRedis().Eval(cachedAggregateSetScript, []string{cacheKey, pendingKey}, version, "0", 0))
+	assert.Equal(t, int64(0), engine.GetRedis().Exists(cacheKey))
+	assert.Equal(t, 1, engine.CachedCount(&cachedAggregateEntity{}, "CountByUser", 5))
+	assert.Equal(t, int64(1), engine.GetRedis().Exists(cacheKey))
+	engine.Flush(&cachedAggregateEntity{UserID: 5, Amount: 1})
+	assert.Equal(t, 2, engine.CachedCount(&cachedAggregateEntity{}, "CountByUser", 5))
+
+	assert.PanicsWithError(t, "index SumByUser not found", func() {
+		engine.CachedCount(&cachedAggregateEntity{}, "SumByUser", 1)
+	})
+
+	registry := &Registry{}
+	registry.RegisterMySQLPool("root:root@tcp(localhost:3311)/test")
+	registry.RegisterEntity(&cachedAggregateInvalidEntity{})
+	_, err := registry.Validate()
+	assert.EqualError(t, err, "cachedCount CountByUser in orm.cachedAggregateInvalidEntity requires redis cache")
+}
@@ -4,13 +4,16 @@ import (
 	"fmt"
 	"math"
 	"reflect"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/segmentio/fasthash/fnv1a"
 )
 
 const idsOnCachePage = 100
+const cacheGenerationTTL = time.Hour * 24
 
 func cachedSearch(engine *Engine, entities interface{}, indexName string, pager *Pager,
 	arguments []interface{}, references []string) (totalRows int, ids []uint64) {
@@ -28,7 +31,8 @@ func cachedSearch(engine *Engine, entities interface{}, indexName string, pager
 		pager = NewPager(1, definition.Max)
 	}
 	start := (pager.GetCurrentPage() - 1) * pager.GetPageSize()
-	if start+pager.GetPageSize() > definition.Max {
+	exceeded := start+pager.GetPageSize() > definition.Max
+	if exceeded && !definition.DBFallback {
 		panic(fmt.Errorf("max cache index page size (%d) exceeded %s", definition.Max, indexName))
 	}
 	localCache, hasLocalCache := schema.GetLocalCache(engine)
@@ -40,8 +44,141 @@ func cachedSearch(engine *Engine, entities interface{}, indexName string, pager
 	if !hasLocalCache && !hasRedis {
 		panic(fmt.Errorf("cache search not allowed for entity without cache: '%s'", entityType.String()))
 	}
+	if exceeded {
+		ids, totalRows = searchIDsWithCount(false, engine, NewWhere(definition.DBQuery, arguments...), pager, entityType)
+		engine.registry.cacheMetrics.add(schema, indexName, CacheLayerDB, 1, 0)
+	} else if definition.Bucket > 0 {
+		totalRows, ids = cachedSearchBucket(engine, schema, entityType, indexName, definition, pager, arguments, localCache, redisCache)
+	} else if len(definition.InArguments) > 0 {
+		totalRows, ids = cachedSearchIn(engine, schema, entityType, indexName, definition, pager, arguments, localCache, redisCache)
+	} else {
+		totalRows, ids = cachedSearchPages(engine, schema, entityType, indexName, definition, pager, arguments, localCache, redisCache)
+	}
+	_, is := entities.(Entity)
+	if !is {
+		tryByIDs(engine, ids, true, value.Elem(), references)
+	}
+	return totalRows, ids
+}
+
+func cachedSearchBucket(engine *Engine, schema *tableSchema, entityType reflect.Type, indexName string, definition *cachedQueryDefinition,
+	pager *Pager, arguments []interface{}, localCache *LocalCache, redisCache *RedisCache) (totalRows int, ids []uint64) {
+	ttl := schema.redisCacheTTL
+	if ttl == 0 {
+		ttl = cacheGenerationTTL
+	}
+	generation := getCacheGenerations(localCache, redisCache, ttl, getCacheKeyGeneration(schema, indexName))[0]
+	cacheKey := getCacheKeySearch(schema, indexName, arguments...) + ":" + generation + ":bucket"
+	matched, has := getCachedBucketIDs(engine, schema, indexName, cacheKey, localCache, redisCache)
+	if !has {
+		bucketPager := NewPager(1, definition.Max)
+		var bucketTotal int
+		var bucketIDs []uint64
+		if len(definition.InArguments) > 0 {
+			bucketTotal, bucketIDs = cachedSearchIn(engine, schema, entityType, indexName, definition, bucketPager, arguments, localCache, redisCache)
+		} else {
+			bucketTotal, bucketIDs = cachedSearchPages(engine, schema, entityType, indexName, definition, bucketPager, arguments, localCache, redisCache)
+		}
+		if bucketTotal > definition.Max {
+			if !definition.DBFallback {
+				panic(fmt.Errorf("max cache index page size (%d) exceeded %s", definition.Max, indexName))
+			}
+			ids, totalRows = searchIDsWithCount(false, engine, NewWhere(definition.DBQuery, arguments...), pager, entityType)
+			engine.registry.cacheMetrics.add(schema, indexName, CacheLayerDB, 1, 0)
+			return totalRows, ids
+		}
+		rows := reflect.New(reflect.SliceOf(reflect.PtrTo(entityType))).Elem()
+		tryByIDs(engine, bucketIDs, true, rows, nil)
+		matched = make([]uint64, 0, rows.Len())
+		for i := 0; i < rows.Len(); i++ {
+			row := rows.Index(i)
+			if matchRangeArguments(definition, row.Elem(), arguments) {
+				matched = append(matched, row.Interface().(Entity).GetID())
+			}
+		}
+		if localCache != nil {
+			localCache.Set(cacheKey, matched)
+		}
+		if redisCache != nil {
+			redisCache.Set(cacheKey, encodeCachedPage(schema.redisCodec, len(matched), matched), int(ttl/time.Second))
+		}
+	}
+	sliceStart := (pager.GetCurrentPage() - 1) * pager.GetPageSize()
+	if sliceStart > len(matched) {
+		return len(matched), []uint64{}
+	}
+	sliceEnd := sliceStart + pager.GetPageSize()
+	if sliceEnd > len(matched) {
+		sliceEnd = len(matched)
+	}
+	return len(matched), matched[sliceStart:sliceEnd]
+}
+
+func getCachedBucketIDs(engine *Engine, schema *tableSchema, indexName string, cacheKey string,
+	localCache *LocalCache, redisCache *RedisCache) (ids []uint64, has bool) {
+	if localCache != nil {
+		value, has := localCache.Get(cacheKey)
+		engine.registry.cacheMetrics.add(schema, indexName, localCacheLayer(localCache), boolToInt(has), boolToInt(!has))
+		if has {
+			return value.([]uint64), true
+		}
+	}
+	if redisCache != nil {
+		value, has := redisCache.Get(cacheKey)
+		engine.registry.cacheMetrics.add(schema, indexName, CacheLayerRedis, boolToInt(has), boolToInt(!has))
+		if has {
+			_, ids = decodeCachedPage(engine.registry, value)
+			if localCache != nil {
+				localCache.Set(cacheKey, ids)
+			}
+			return ids, true
+		}
+	}
+	return nil, false
+}
+
+func cachedSearchIn(engine *Engine, schema *tableSchema, entityType reflect.Type, indexName string, definition *cachedQueryDefinition,
+	pager *Pager, arguments []interface{}, localCache *LocalCache, redisCache *RedisCache) (totalRows int, ids []uint64) {
+	valuesPager := NewPager(1, pager.GetCurrentPage()*pager.GetPageSize())
+	merged := make([]uint64, 0)
+	for _, valueArguments := range expandInArguments(definition, arguments) {
+		total, valueIDs := cachedSearchPages(engine, schema, entityType, indexName, definition, valuesPager, valueArguments, localCache, redisCache)
+		totalRows += total
+		merged = append(merged, valueIDs...)
+	}
+	sort.Slice(merged, func(i, j int) bool {
+		if definition.OrderDesc {
+			return merged[i] > merged[j]
+		}
+		return merged[i] < merged[j]
+	})
+	sliceStart := (pager.GetCurrentPage() - 1) * pager.GetPageSize()
+	if sliceStart > len(merged) {
+		return totalRows, []uint64{}
+	}
+	sliceEnd := sliceStart + pager.GetPageSize()
+	if sliceEnd > len(merged) {
+		sliceEnd = len(merged)
+	}
+	return totalRows, merged[sliceStart:sliceEnd]
+}
+
+func cachedSearchPages(engine *Engine, schema *tableSchema, entityType reflect.Type, indexName string, definition *cachedQueryDefinition,
+	pager *Pager, arguments []interface{}, localCache *LocalCache, redisCache *RedisCache) (totalRows int, ids []uint64) {
+	hasLocalCache := localCache != nil
+	hasRedis := redisCache != nil
+	if definition.Bucket > 0 {
+		arguments = bucketRangeArguments(definition, arguments)
+	}
 	where := NewWhere(definition.Query, arguments...)
 	cacheKey := getCacheKeySearch(schema, indexName, where.GetParameters()...)
+	pagesTTL := schema.redisCacheTTL
+	if len(definition.RangeArguments) > 0 {
+		if pagesTTL == 0 {
+			pagesTTL = cacheGenerationTTL
+		}
+		cacheKey += ":" + getCacheGenerations(localCache, redisCache, pagesTTL, getCacheKeyGeneration(schema, indexName))[0]
+	}
 
 	minCachePage := float64((pager.GetCurrentPage() - 1) * pager.GetPageSize() / idsOnCachePage)
 	minCachePageCeil := minCachePage
@@ -54,7 +191,7 @@ func cachedSearch(engine *Engine, entities interface{}, indexName string, pager
 		j++
 	}
 	filledPages := make(map[string][]uint64)
-	fromRedis := false
+
 	var fromCache map[string]interface{}
 	var nilsKeys []string
 	if hasLocalCache {
@@ -65,15 +202,27 @@ func cachedSearch(engine *Engine, entities interface{}, indexName string, pager
 				nilsKeys = append(nilsKeys, key)
 			}
 		}
+		engine.registry.cacheMetrics.add(schema, indexName, localCacheLayer(localCache), len(pages)-len(nilsKeys), len(nilsKeys))
 		if hasRedis && len(nilsKeys) > 0 {
 			fromRedis := redisCache.HMget(cacheKey, nilsKeys...)
+			redisMisses := 0
 			for key, idsFromRedis := range fromRedis {
 				fromCache[key] = idsFromRedis
+				if idsFromRedis == nil {
+					redisMisses++
+				}
 			}
+			engine.registry.cacheMetrics.add(schema, indexName, CacheLayerRedis, len(fromRedis)-redisMisses, redisMisses)
 		}
 	} else if hasRedis {
-		fromRedis = true
 		fromCache = redisCache.HMget(cacheKey, pages...)
+		redisMisses := 0
+		for _, idsFromRedis := range fromCache {
+			if idsFromRedis == nil {
+				redisMisses++
+			}
+		}
+		engine.registry.cacheMetrics.add(schema, indexName, CacheLayerRedis, len(fromCache)-redisMisses, redisMisses)
 	}
 	hasNil := false
 	totalRows = 0
@@ -90,17 +239,10 @@ func cachedSearch(engine *Engine, entities interface{}, indexName string, pager
 				maxPage = p
 			}
 		} else {
-			if fromRedis {
-				ids := strings.Split(idsSlice.(string), " ")
-				totalRows, _ = strconv.Atoi(ids[0])
-				length := len(ids)
-				idsAsUint := make([]uint64, length-1)
-				for i := 1; i < length; i++ {
-					idsAsUint[i-1], _ = strconv.ParseUint(ids[i], 10, 64)
-				}
-				filledPages[key] = idsAsUint
-			} else {
-				ids := idsSlice.([]uint64)
+			switch ids := idsSlice.(type) {
+			case string:
+				totalRows, filledPages[key] = decodeCachedPage(engine.registry, ids)
+			case []uint64:
 				totalRows = int(ids[0])
 				filledPages[key] = ids[1:]
 			}
@@ -108,17 +250,26 @@ func cachedSearch(engine *Engine, entities interface{}, indexName string, pager
 	}
 
 	if hasNil {
-		searchPager := NewPager(minPage, maxPage*idsOnCachePage)
-		results, total := searchIDsWithCount(false, engine, where, searchPager, entityType)
+		var results []uint64
+		var total int
+		fresh := true
+		if schema.GetMysql(engine).inTransaction {
+			searchPager := NewPager(minPage, maxPage*idsOnCachePage)
+			results, total = searchIDsWithCount(false, engine, where, searchPager, entityType)
+		} else {
+			results, total, fresh = cachedSearchFromDBProtected(engine, schema, entityType, where, cacheKey, minPage, maxPage, redisCache)
+		}
 		totalRows = total
 		cacheFields := make([]interface{}, 0)
+		dbPages := 0
 		for key, ids := range fromCache {
 			if ids == nil {
+				dbPages++
 				page := key
 				pageInt, _ := strconv.Atoi(page)
 				sliceStart := (pageInt - minPage) * idsOnCachePage
 				if sliceStart > total {
-					cacheFields = append(cacheFields, page, total)
+					cacheFields = append(cacheFields, page, encodeCachedPage(schema.redisCodec, total, nil))
 					continue
 				}
 				sliceEnd := sliceStart + idsOnCachePage
@@ -127,23 +278,23 @@ func cachedSearch(engine *Engine, entities interface{}, indexName string, pager
 				}
 				l := len(results)
 				if l == 0 {
-					cacheFields = append(cacheFields, page, total)
+					cacheFields = append(cacheFields, page, encodeCachedPage(schema.redisCodec, total, nil))
 					continue
 				}
 				if sliceEnd > l {
 					sliceEnd = l
 				}
-				values := []uint64{uint64(total)}
 				foundIDs := results[sliceStart:sliceEnd]
 				filledPages[key] = foundIDs
-				values = append(values, foundIDs...)
-				cacheValue := fmt.Sprintf("%v", values)
-				cacheValue = strings.Trim(cacheValue, "[]")
-				cacheFields = append(cacheFields, page, cacheValue)
+				cacheFields = append(cacheFields, page, encodeCachedPage(schema.redisCodec, total, foundIDs))
 			}
 		}
-		if hasRedis {
+		engine.registry.cacheMetrics.add(schema, indexName, CacheLayerDB, dbPages, 0)
+		if hasRedis && fresh {
 			redisCache.HSet(cacheKey, cacheFields...)
+			if pagesTTL > 0 {
+				redisCache.Expire(cacheKey, pagesTTL)
+			}
 		}
 	}
 	nilKeysLen := len(nilsKeys)
@@ -172,12 +323,45 @@ func cachedSearch(engine *Engine, entities interface{}, indexName string, pager
 	if sliceEnd > length {
 		sliceEnd = length
 	}
-	idsToReturn := resultsIDs[sliceStart:sliceEnd]
-	_, is := entities.(Entity)
-	if !is {
-		tryByIDs(engine, idsToReturn, true, value.Elem(), references)
+	return totalRows, resultsIDs[sliceStart:sliceEnd]
+}
+
+type cachedSearchRows struct {
+	ids   []uint64
+	total int
+}
+
+func cachedSearchFromDBProtected(engine *Engine, schema *tableSchema, entityType reflect.Type, where *Where, cacheKey string,
+	minPage, maxPage int, redisCache *RedisCache) (ids []uint64, total int, fresh bool) {
+	var check func() (interface{}, bool)
+	if redisCache != nil {
+		check = func() (interface{}, bool) {
+			pages := make([]string, 0, maxPage-minPage+1)
+			for i := minPage; i <= maxPage; i++ {
+				pages = append(pages, strconv.Itoa(i))
+			}
+			fromRedis := redisCache.HMget(cacheKey, pages...)
+			rows := &cachedSearchRows{ids: make([]uint64, 0)}
+			for _, page := range pages {
+				value := fromRedis[page]
+				if value == nil {
+					return nil, false
+				}
+				total, ids := decodeCachedPage(engine.registry, value.(string))
+				rows.total = total
+				rows.ids = append(rows.ids, ids...)
+			}
+			return rows, true
+		}
 	}
-	return totalRows, idsToReturn
+	key := cacheKey + ":" + strconv.Itoa(minPage) + ":" + strconv.Itoa(maxPage)
+	value, fresh := engine.registry.stampedeProtection.do(engine, key, check, func() interface{} {
+		searchPager := NewPager(minPage, maxPage*idsOnCachePage)
+		results, total := searchIDsWithCount(false, engine, where, searchPager, entityType)
+		return &cachedSearchRows{ids: results, total: total}
+	})
+	rows := value.(*cachedSearchRows)
+	return rows.ids, rows.total, fresh
 }
 
 func cachedSearchOne(engine *Engine, entity Entity, indexName string, arguments []interface{}, references []string) (has bool) {
@@ -223,6 +407,9 @@ func cachedSearchOne(engine *Engine, entity Entity, indexName string, arguments
 		}
 		if hasRedis {
 			redisCache.HSet(cacheKey, "1", value)
+			if schema.redisCacheTTL > 0 {
+				redisCache.Expire(cacheKey, schema.redisCacheTTL)
+			}
 		}
 	} else {
 		ids := strings.Split(fromCache["1"].(string), " ")
@@ -236,6 +423,215 @@ func cachedSearchOne(engine *Engine, entity Entity, indexName string, arguments
 	return false
 }
 
+func getCacheKeyGeneration(tableSchema *tableSchema, indexName string) string {
+	return tableSchema.cachePrefix + "_" + indexName + ":generation"
+}
+
+func getCacheGenerations(localCache *LocalCache, redisCache *RedisCache, ttl time.Duration, keys ...string) []string {
+	generations := make([]string, len(keys))
+	missing := make(map[string][]int)
+	for i, key := range keys {
+		if localCache != nil {
+			generation, has := localCache.Get(key)
+			if has {
+				generations[i] = generation.(string)
+				continue
+			}
+		}
+		missing[key] = append(missing[key], i)
+	}
+	if len(missing) == 0 {
+		return generations
+	}
+	missingKeys := make([]string, 0, len(missing))
+	for key := range missing {
+		missingKeys = append(missingKeys, key)
+	}
+	fromRedis := make(map[string]interface{})
+	if redisCache != nil {
+		fromRedis = redisCache.MGet(missingKeys...)
+	}
+	for _, key := range missingKeys {
+		generation, _ := fromRedis[key].(string)
+		if generation == "" {
+			generation = strconv.FormatInt(time.Now().UnixNano(), 36)
+			if redisCache != nil {
+				if ttl < cacheGenerationTTL {
+					ttl = cacheGenerationTTL
+				}
+				redisCache.Set(key, generation, int(ttl/time.Second))
+			}
+		}
+		if localCache != nil {
+			localCache.Set(key, generation)
+		}
+		for _, i := range missing[key] {
+			generations[i] = generation
+		}
+	}
+	return generations
+}
+
+func expandInArguments(definition *cachedQueryDefinition, arguments []interface{}) [][]interface{} {
+	combinations := [][]interface{}{make([]interface{}, 0, len(arguments))}
+	for i, argument := range arguments {
+		isIn := false
+		for _, position := range definition.InArguments {
+			if position == i {
+				isIn = true
+				break
+			}
+		}
+		values := []interface{}{argument}
+		if isIn {
+			values = uniqueSliceValues(argument)
+		}
+		next := make([][]interface{}, 0, len(combinations)*len(values))
+		for _, combination := range combinations {
+			for _, v := range values {
+				row := make([]interface{}, len(combination), len(combination)+1)
+				copy(row, combination)
+				next = append(next, append(row, v))
+			}
+		}
+		combinations = next
+	}
+	return combinations
+}
+
+func uniqueSliceValues(argument interface{}) []interface{} {
+	val := reflect.ValueOf(argument)
+	if val.Kind() != reflect.Slice && val.Kind() != reflect.Array {
+		return []interface{}{argument}
+	}
+	values := make([]interface{}, 0, val.Len())
+	added := make(map[string]bool, val.Len())
+	for i := 0; i < val.Len(); i++ {
+		v := val.Index(i).Interface()
+		key := fmt.Sprintf("%v", v)
+		if !added[key] {
+			added[key] = true
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+func bucketRangeArguments(definition *cachedQueryDefinition, arguments []interface{}) []interface{} {
+	bucketed := make([]interface{}, len(arguments))
+	copy(bucketed, arguments)
+	for i, position := range definition.RangeArguments {
+		if position >= len(bucketed) {
+			continue
+		}
+		roundUp := i < len(definition.RangeOperators) && definition.RangeOperators[i][0] == '<'
+		round := math.Floor
+		if roundUp {
+			round = math.Ceil
+		}
+		switch v := bucketed[position].(type) {
+		case time.Time:
+			bucket := time.Duration(definition.Bucket) * time.Second
+			truncated := v.Truncate(bucket)
+			if truncated.Before(v) && roundUp {
+				truncated = truncated.Add(bucket)
+			}
+			bucketed[position] = truncated
+		default:
+			val := reflect.ValueOf(v)
+			switch val.Kind() {
+			case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+				bucketed[position] = int64(round(float64(val.Int())/definition.Bucket) * definition.Bucket)
+			case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+				bucketed[position] = uint64(round(float64(val.Uint())/definition.Bucket) * definition.Bucket)
+			case reflect.Float32, reflect.Float64:
+				bucketed[position] = round(val.Float()/definition.Bucket) * definition.Bucket
+			}
+		}
+	}
+	return bucketed
+}
+
+func matchRangeArguments(definition *cachedQueryDefinition, entity reflect.Value, arguments []interface{}) bool {
+	for i, position := range definition.RangeArguments {
+		if position >= len(arguments) {
+			continue
+		}
+		field := entity.FieldByName(definition.RangeFields[i])
+		if field.Kind() == reflect.Ptr {
+			if field.IsNil() {
+				return false
+			}
+			field = field.Elem()
+		}
+		compared, comparable := compareRangeValue(field, arguments[position])
+		if !comparable {
+			continue
+		}
+		switch definition.RangeOperators[i] {
+		case ">=":
+			if compared < 0 {
+				return false
+			}
+		case ">":
+			if compared <= 0 {
+				return false
+			}
+		case "<=":
+			if compared > 0 {
+				return false
+			}
+		case "<":
+			if compared >= 0 {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func compareRangeValue(field reflect.Value, bound interface{}) (compared int, comparable bool) {
+	boundTime, isTime := bound.(time.Time)
+	if isTime {
+		fieldTime, is := field.Interface().(time.Time)
+		if !is {
+			return 0, false
+		}
+		if fieldTime.Before(boundTime) {
+			return -1, true
+		} else if fieldTime.After(boundTime) {
+			return 1, true
+		}
+		return 0, true
+	}
+	a, is := rangeFloatValue(field)
+	if !is {
+		return 0, false
+	}
+	b, is := rangeFloatValue(reflect.ValueOf(bound))
+	if !is {
+		return 0, false
+	}
+	if a < b {
+		return -1, true
+	} else if a > b {
+		return 1, true
+	}
+	return 0, true
+}
+
+func rangeFloatValue(val reflect.Value) (float64, bool) {
+	switch val.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(val.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(val.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return val.Float(), true
+	}
+	return 0, false
+}
+
 func getCacheKeySearch(tableSchema *tableSchema, indexName string, parameters ...interface{}) string {
 	return tableSchema.cachePrefix + "_" + indexName + strconv.Itoa(int(fnv1a.HashString32(fmt.Sprintf("%v", parameters))))
 }
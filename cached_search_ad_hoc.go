@@ -0,0 +1,121 @@
+package orm
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/segmentio/fasthash/fnv1a"
+)
+
+const adHocSearchIndex = "adhoc"
+
+var adHocSearchWordRegexp = regexp.MustCompile("[A-Za-z0-9_]+")
+
+type adHocSearchValue struct {
+	expires int64
+	total   int
+	ids     []uint64
+}
+
+func cachedSearchAdHoc(engine *Engine, where *Where, pager *Pager, entities interface{}, ttl time.Duration) (totalRows int) {
+	value := reflect.ValueOf(entities)
+	entityType, has, name := getEntityTypeForSlice(engine.registry, value.Type())
+	if !has {
+		panic(fmt.Errorf("entity '%s' is not registered", name))
+	}
+	schema := getTableSchema(engine.registry, entityType)
+	if ttl <= 0 {
+		panic(fmt.Errorf("invalid ad hoc cache ttl %s", ttl))
+	}
+	if pager == nil {
+		pager = NewPager(1, 50000)
+	}
+	localCache, hasLocalCache := schema.GetLocalCache(engine)
+	if !hasLocalCache && engine.hasRequestCache {
+		hasLocalCache = true
+		localCache = engine.GetLocalCache(requestCacheKey)
+	}
+	redisCache, hasRedis := schema.GetRedisCache(engine)
+	if !hasLocalCache && !hasRedis {
+		panic(fmt.Errorf("cache search not allowed for entity without cache: '%s'", entityType.String()))
+	}
+	generations := getCacheGenerations(localCache, redisCache, ttl, getAdHocSearchTags(schema, where.String())...)
+	cacheKey := schema.cachePrefix + "_" + adHocSearchIndex + strconv.FormatUint(fnv1a.HashString64(fmt.Sprintf("%s %v %d %d %v",
+		where.String(), where.GetParameters(), pager.GetCurrentPage(), pager.GetPageSize(), generations)), 36)
+
+	var ids []uint64
+	found := false
+	if hasLocalCache {
+		fromCache, has := localCache.Get(cacheKey)
+		if has {
+			cached := fromCache.(*adHocSearchValue)
+			if cached.expires > time.Now().UnixNano() {
+				totalRows, ids, found = cached.total, cached.ids, true
+			}
+		}
+		engine.registry.cacheMetrics.add(schema, adHocSearchIndex, localCacheLayer(localCache), boolToInt(found), boolToInt(!found))
+	}
+	if !found && hasRedis {
+		fromCache, has := redisCache.Get(cacheKey)
+		if has {
+			totalRows, ids = decodeCachedPage(engine.registry, fromCache)
+			found = true
+		}
+		engine.registry.cacheMetrics.add(schema, adHocSearchIndex, CacheLayerRedis, boolToInt(found), boolToInt(!found))
+		if found && hasLocalCache {
+			localCache.Set(cacheKey, &adHocSearchValue{expires: time.Now().Add(ttl).UnixNano(), total: totalRows, ids: ids})
+		}
+	}
+	if !found {
+		ids, totalRows = searchIDsWithCount(true, engine, where, pager, entityType)
+		engine.registry.cacheMetrics.add(schema, adHocSearchIndex, CacheLayerDB, 1, 0)
+		if hasLocalCache {
+			localCache.Set(cacheKey, &adHocSearchValue{expires: time.Now().Add(ttl).UnixNano(), total: totalRows, ids: ids})
+		}
+		if hasRedis {
+			redisCache.Set(cacheKey, encodeCachedPage(schema.redisCodec, totalRows, ids), int(math.Ceil(ttl.Seconds())))
+		}
+	}
+	tryByIDs(engine, ids, true, value.Elem(), nil)
+	return totalRows
+}
+
+func getAdHocSearchTags(schema *tableSchema, query string) []string {
+	tags := []string{getAdHocSearchTypeTag(schema)}
+	added := make(map[string]bool)
+	if schema.hasFakeDelete {
+		tags = append(tags, getAdHocSearchColumnTag(schema, "FakeDelete"))
+		added["FakeDelete"] = true
+	}
+	for _, word := range adHocSearchWordRegexp.FindAllString(query, -1) {
+		_, isColumn := schema.columnMapping[word]
+		if isColumn && !added[word] {
+			tags = append(tags, getAdHocSearchColumnTag(schema, word))
+			added[word] = true
+		}
+	}
+	return tags
+}
+
+func getAdHocSearchTagsKeys(schema *tableSchema, bind map[string]interface{}, addedDeleted bool) []string {
+	if addedDeleted {
+		return []string{getAdHocSearchTypeTag(schema)}
+	}
+	keys := make([]string, 0, len(bind))
+	for column := range bind {
+		keys = append(keys, getAdHocSearchColumnTag(schema, column))
+	}
+	return keys
+}
+
+func getAdHocSearchTypeTag(schema *tableSchema) string {
+	return schema.cachePrefix + "_" + adHocSearchIndex + ":generation"
+}
+
+func getAdHocSearchColumnTag(schema *tableSchema, column string) string {
+	return schema.cachePrefix + "_" + adHocSearchIndex + ":" + column + ":generation"
+}
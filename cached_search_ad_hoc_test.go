@@ -0,0 +1,67 @@
+package orm
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type cachedSearchAdHocEntity struct {
+	ORM        `orm:"localCache;redisCache"`
+	ID         uint
+	Name       string
+	Age        uint16
+	Code       string
+	FakeDelete bool
+}
+
+func TestCachedSearchAdHoc(t *testing.T) {
+	var entity *cachedSearchAdHocEntity
+	engine := PrepareTables(t, &Registry{}, 5, entity)
+	engine.FlushMany(&cachedSearchAdHocEntity{Name: "a", Age: 10, Code: "x"}, &cachedSearchAdHocEntity{Name: "b", Age: 20, Code: "x"},
+		&cachedSearchAdHocEntity{Name: "c", Age: 30, Code: "y"})
+
+	var rows []*cachedSearchAdHocEntity
+	where := NewWhere("`Age` >= ?", 20)
+	assert.Equal(t, 2, engine.CachedSearchAdHoc(where, nil, &rows, time.Minute))
+	assert.Len(t, rows, 2)
+	assert.Equal(t, uint(2), rows[0].ID)
+
+	engine.ResetCacheMetrics()
+	assert.Equal(t, 2, engine.CachedSearchAdHoc(where, nil, &rows, time.Minute))
+	assert.Equal(t, 1, engine.GetCacheMetrics()[0].Hits)
+
+	engine.LoadByID(3, entity)
+	entity.Code = "z"
+	engine.Flush(entity)
+	engine.ResetCacheMetrics()
+	assert.Equal(t, 2, engine.CachedSearchAdHoc(where, nil, &rows, time.Minute))
+	for _, metric := range engine.GetCacheMetrics() {
+		assert.NotEqual(t, CacheLayerDB, metric.Layer)
+	}
+
+	entity.Age = 5
+	engine.Flush(entity)
+	assert.Equal(t, 1, engine.CachedSearchAdHoc(where, nil, &rows, time.Minute))
+	assert.Equal(t, uint(2), rows[0].ID)
+
+	engine.Flush(&cachedSearchAdHocEntity{Name: "d", Age: 40})
+	assert.Equal(t, 2, engine.CachedSearchAdHoc(where, nil, &rows, time.Minute))
+
+	engine.UpdateFields(entity, 2, Bind{"Age": 1})
+	assert.Equal(t, 1, engine.CachedSearchAdHoc(where, nil, &rows, time.Minute))
+	assert.Equal(t, uint(4), rows[0].ID)
+
+	engine.LoadByID(4, entity)
+	engine.Delete(entity)
+	assert.Equal(t, 0, engine.CachedSearchAdHoc(where, nil, &rows, time.Minute))
+
+	assert.Equal(t, 2, engine.CachedSearchAdHoc(NewWhere("`Code` = ?", "x"), NewPager(1, 1), &rows, time.Minute))
+	assert.Len(t, rows, 1)
+	assert.Equal(t, uint(1), rows[0].ID)
+
+	assert.PanicsWithError(t, "invalid ad hoc cache ttl 0s", func() {
+		engine.CachedSearchAdHoc(where, nil, &rows, 0)
+	})
+}
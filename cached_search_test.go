@@ -1,7 +1,9 @@
 package orm
 
 import (
+	"context"
 	"fmt"
+	"reflect"
 	"strconv"
 	"testing"
 	"time"
@@ -34,6 +36,33 @@ type cachedSearchRefEntity struct {
 	IndexAll  *CachedQuery `query:""`
 }
 
+type cachedSearchInRangeEntity struct {
+	ORM         `orm:"localCache;redisCache"`
+	ID          uint
+	Status      string       `orm:"index=Status"`
+	Price       int          `orm:"index=Price"`
+	IndexStatus *CachedQuery `query:":Status IN ?"`
+	IndexPrice  *CachedQuery `query:":Price >= ?" orm:"bucket=10"`
+	IndexLower  *CachedQuery `query:":Price < ?" orm:"bucket=10"`
+	IndexMax    *CachedQuery `query:":Price >= ?" orm:"bucket=10;max=2"`
+	IndexDB     *CachedQuery `query:":Price >= ?" orm:"bucket=10;max=2;dbFallback"`
+}
+
+type cachedSearchBucketOrEntity struct {
+	ORM        `orm:"localCache"`
+	ID         uint
+	Price      int
+	IndexPrice *CachedQuery `query:":Price >= ? OR :ID = ?" orm:"bucket=10"`
+}
+
+type cachedSearchMaxEntity struct {
+	ORM      `orm:"localCache"`
+	ID       uint
+	Age      int          `orm:"index=Age"`
+	IndexAll *CachedQuery `query:"" orm:"max=3;dbFallback"`
+	IndexAge *CachedQuery `query:":Age = ?" orm:"max=2"`
+}
+
 func TestCachedSearchLocal(t *testing.T) {
 	testCachedSearch(t, true, false)
 }
@@ -276,6 +305,147 @@ func TestCachedSearchErrors(t *testing.T) {
 	})
 }
 
+func TestCachedSearchInAndRange(t *testing.T) {
+	var entity *cachedSearchInRangeEntity
+	engine := PrepareTables(t, &Registry{}, 5, entity)
+	schema := engine.GetRegistry().GetTableSchemaForEntity(entity).(*tableSchema)
+	assert.Equal(t, "`Status` = ?", schema.cachedIndexes["IndexStatus"].Query)
+	assert.Equal(t, []int{0}, schema.cachedIndexes["IndexStatus"].InArguments)
+	assert.Equal(t, []int{0}, schema.cachedIndexes["IndexPrice"].RangeArguments)
+	assert.Equal(t, float64(10), schema.cachedIndexes["IndexPrice"].Bucket)
+
+	statuses := []string{"a", "b", "c"}
+	for i := 1; i <= 9; i++ {
+		engine.Flush(&cachedSearchInRangeEntity{Status: statuses[i%3], Price: i * 5})
+	}
+
+	var rows []*cachedSearchInRangeEntity
+	totalRows := engine.CachedSearch(&rows, "IndexStatus", nil, []string{"a", "b", "a"})
+	assert.Equal(t, 6, totalRows)
+	assert.Len(t, rows, 6)
+	assert.Equal(t, uint(1), rows[0].ID)
+	assert.Equal(t, uint(3), rows[1].ID)
+	assert.Equal(t, uint(4), rows[2].ID)
+	totalRows = engine.CachedSearch(&rows, "IndexStatus", NewPager(2, 4), []string{"a", "b"})
+	assert.Equal(t, 6, totalRows)
+	assert.Len(t, rows, 2)
+	assert.Equal(t, uint(7), rows[0].ID)
+	assert.Equal(t, uint(9), rows[1].ID)
+
+	engine.Flush(&cachedSearchInRangeEntity{Status: "b", Price: 100})
+	totalRows = engine.CachedSearch(&rows, "IndexStatus", nil, []string{"a", "b"})
+	assert.Equal(t, 7, totalRows)
+	assert.Equal(t, uint(10), rows[6].ID)
+
+	totalRows = engine.CachedSearch(&rows, "IndexPrice", nil, 34)
+	assert.Equal(t, 4, totalRows)
+	assert.Len(t, rows, 4)
+	assert.Equal(t, uint(7), rows[0].ID)
+	totalRows = engine.CachedSearch(&rows, "IndexPrice", NewPager(2, 3), 34)
+	assert.Equal(t, 4, totalRows)
+	assert.Len(t, rows, 1)
+	assert.Equal(t, uint(10), rows[0].ID)
+	totalRows = engine.CachedSearch(&rows, "IndexPrice", nil, 30)
+	assert.Equal(t, 5, totalRows)
+	totalRows = engine.CachedSearch(&rows, "IndexLower", nil, 34)
+	assert.Equal(t, 6, totalRows)
+	assert.Equal(t, uint(6), rows[5].ID)
+	assert.PanicsWithError(t, "max cache index page size (2) exceeded IndexMax", func() {
+		engine.CachedSearch(&rows, "IndexMax", nil, 34)
+	})
+	totalRows = engine.CachedSearch(&rows, "IndexDB", nil, 34)
+	assert.Equal(t, 4, totalRows)
+	assert.Len(t, rows, 2)
+	assert.Equal(t, uint(7), rows[0].ID)
+
+	generationKey := getCacheKeyGeneration(schema, "IndexPrice")
+	generation, has := engine.GetRedis().Get(generationKey)
+	assert.True(t, has)
+	ttl := engine.GetRedis().client.TTL(context.Background(), generationKey).Val()
+	assert.Greater(t, int64(ttl), int64(cacheGenerationTTL-time.Minute))
+	pageKey := getCacheKeySearch(schema, "IndexPrice", 30) + ":" + generation
+	ttl = engine.GetRedis().client.TTL(context.Background(), pageKey).Val()
+	assert.Greater(t, int64(ttl), int64(cacheGenerationTTL-time.Minute))
+	bucketKey := getCacheKeySearch(schema, "IndexPrice", 34) + ":" + generation + ":bucket"
+	bucketIDs, has := engine.GetLocalCache().Get(bucketKey)
+	assert.True(t, has)
+	assert.Equal(t, []uint64{7, 8, 9, 10}, bucketIDs)
+	encoded, has := engine.GetRedis().Get(bucketKey)
+	assert.True(t, has)
+	assert.Equal(t, "4 7 8 9 10", encoded)
+
+	entity = &cachedSearchInRangeEntity{}
+	engine.LoadByID(1, entity)
+	entity.Price = 200
+	engine.Flush(entity)
+	totalRows = engine.CachedSearch(&rows, "IndexPrice", nil, 30)
+	assert.Equal(t, 6, totalRows)
+	engine.Delete(rows[0])
+	totalRows = engine.CachedSearch(&rows, "IndexPrice", nil, 30)
+	assert.Equal(t, 5, totalRows)
+	engine.Flush(&cachedSearchInRangeEntity{Status: "a", Price: 50})
+	totalRows = engine.CachedSearch(&rows, "IndexPrice", nil, 30)
+	assert.Equal(t, 6, totalRows)
+
+	engine.GetLocalCache().Clear()
+	totalRows = engine.CachedSearch(&rows, "IndexPrice", nil, 30)
+	assert.Equal(t, 6, totalRows)
+
+	assert.Equal(t, []interface{}{int64(30), uint64(10), 20.0, "x"},
+		bucketRangeArguments(&cachedQueryDefinition{RangeArguments: []int{0, 1, 2}, Bucket: 10}, []interface{}{34, uint(15), 29.9, "x"}))
+	lower := &cachedQueryDefinition{RangeArguments: []int{0, 1}, RangeFields: []string{"Price", "ID"}, RangeOperators: []string{"<=", ">"}, Bucket: 10}
+	assert.Equal(t, []interface{}{int64(40), int64(0)}, bucketRangeArguments(lower, []interface{}{34, 5}))
+	assert.True(t, matchRangeArguments(lower, reflect.ValueOf(cachedSearchInRangeEntity{ID: 6, Price: 34}), []interface{}{34, 5}))
+	assert.False(t, matchRangeArguments(lower, reflect.ValueOf(cachedSearchInRangeEntity{ID: 6, Price: 35}), []interface{}{34, 5}))
+	assert.False(t, matchRangeArguments(lower, reflect.ValueOf(cachedSearchInRangeEntity{ID: 5, Price: 30}), []interface{}{34, 5}))
+
+	registry := &Registry{}
+	registry.RegisterMySQLPool("root:root@tcp(localhost:3311)/test")
+	registry.RegisterLocalCache(100)
+	registry.RegisterEntity(&cachedSearchBucketOrEntity{})
+	_, err := registry.Validate()
+	assert.EqualError(t, err, "bucket can't be used with OR in cached query IndexPrice in orm.cachedSearchBucketOrEntity")
+	assert.Equal(t, [][]interface{}{{"a", 1}, {"b", 1}}, expandInArguments(&cachedQueryDefinition{InArguments: []int{0}},
+		[]interface{}{[]string{"a", "b", "a"}, 1}))
+}
+
+func TestCachedSearchMax(t *testing.T) {
+	var entity *cachedSearchMaxEntity
+	engine := PrepareTables(t, &Registry{}, 5, entity)
+	schema := engine.GetRegistry().GetTableSchemaForEntity(entity).(*tableSchema)
+	assert.Equal(t, 3, schema.cachedIndexes["IndexAll"].Max)
+	assert.True(t, schema.cachedIndexes["IndexAll"].DBFallback)
+	assert.Equal(t, 2, schema.cachedIndexes["IndexAge"].Max)
+	assert.False(t, schema.cachedIndexes["IndexAge"].DBFallback)
+	for i := 1; i <= 5; i++ {
+		engine.Flush(&cachedSearchMaxEntity{Age: 10})
+	}
+
+	var rows []*cachedSearchMaxEntity
+	totalRows := engine.CachedSearch(&rows, "IndexAll", nil)
+	assert.Equal(t, 5, totalRows)
+	assert.Len(t, rows, 3)
+	engine.ResetCacheMetrics()
+	totalRows = engine.CachedSearch(&rows, "IndexAll", NewPager(2, 2))
+	assert.Equal(t, 5, totalRows)
+	assert.Len(t, rows, 2)
+	assert.Equal(t, uint(3), rows[0].ID)
+	assert.Equal(t, uint(4), rows[1].ID)
+	metrics := engine.GetCacheMetrics()
+	assert.Equal(t, CacheLayerDB, metrics[len(metrics)-1].Layer)
+	assert.Equal(t, "IndexAll", metrics[len(metrics)-1].Query)
+	totalRows = engine.CachedSearch(&rows, "IndexAll", NewPager(1, 3))
+	assert.Equal(t, 5, totalRows)
+	assert.Len(t, rows, 3)
+
+	totalRows = engine.CachedSearch(&rows, "IndexAge", nil, 10)
+	assert.Equal(t, 5, totalRows)
+	assert.Len(t, rows, 2)
+	assert.PanicsWithError(t, "max cache index page size (2) exceeded IndexAge", func() {
+		_ = engine.CachedSearch(&rows, "IndexAge", NewPager(2, 2), 10)
+	})
+}
+
 func BenchmarkFillStructDefault(b *testing.B) {
 	entity := &schemaEntity{}
 	ref := &schemaEntityRef{}
@@ -13,6 +13,7 @@ func clearByIDs(engine *Engine, entity Entity, ids ...uint64) {
 	}
 	if has {
 		localCache.Remove(cacheKeys...)
+		publishLocalCacheInvalidation(engine, localCache.code, cacheKeys...)
 	}
 	redisCache, has := schema.GetRedisCache(engine)
 	if has {
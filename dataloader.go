@@ -5,8 +5,6 @@ import (
 	"strings"
 	"sync"
 	"time"
-
-	jsoniter "github.com/json-iterator/go"
 )
 
 const dataLoaderMaxPatch = 200
@@ -52,6 +50,12 @@ func (l *dataLoader) Prime(schema TableSchema, id uint64, value []interface{}) {
 	l.mu.Unlock()
 }
 
+func (l *dataLoader) remove(schema TableSchema, id uint64) {
+	l.mu.Lock()
+	delete(l.cache, l.key(schema, id))
+	l.mu.Unlock()
+}
+
 func (l *dataLoader) Clear() {
 	l.mu.Lock()
 	l.cache = nil
@@ -191,13 +195,12 @@ func (b *dataLoaderBatch) end(l *dataLoader) {
 					if val == nil {
 						toSet = "nil"
 					} else {
-						encoded, _ := jsoniter.ConfigFastest.Marshal(val)
-						toSet = string(encoded)
+						toSet = encodeRedisRow(schema.redisCodec, val)
 					}
 					pairs[i+1] = toSet
 					i += 2
 				}
-				redisCache.MSet(pairs...)
+				redisCache.mSetWithTTL(schema.redisSetTTL(), pairs...)
 			}
 		}
 	}
@@ -220,9 +223,7 @@ func (b *dataLoaderBatch) getKeysForNils(l *dataLoader, schema *tableSchema, row
 			if v == "nil" {
 				resultsKeys[k] = nil
 			} else {
-				var decoded []interface{}
-				_ = jsoniter.ConfigFastest.Unmarshal([]byte(v.(string)), &decoded)
-				convertDataFromJSON(schema.fields, 0, decoded)
+				decoded := decodeRedisRow(l.engine.registry, schema, v.(string))
 				resultsKeys[k] = decoded
 				results[l.key(schema, keyMapping[k])] = decoded
 			}
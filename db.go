@@ -221,6 +221,7 @@ func (db *DB) Commit() {
 		for cacheCode, pairs := range db.engine.afterCommitLocalCacheSets {
 			cache := db.engine.GetLocalCache(cacheCode)
 			cache.MSet(pairs...)
+			publishLocalCacheSetsInvalidation(db.engine, cacheCode, pairs)
 		}
 		db.engine.afterCommitLocalCacheSets = nil
 	}
@@ -251,6 +252,7 @@ func (db *DB) Rollback() {
 	checkError(err)
 	db.engine.afterCommitLocalCacheSets = nil
 	db.engine.afterCommitRedisFlusher = nil
+	db.engine.afterCommitDataLoaderSets = nil
 }
 
 func (db *DB) Exec(query string, args ...interface{}) ExecResult {
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"reflect"
+	"sort"
 	"strings"
 	"time"
 
@@ -73,6 +74,52 @@ func (e *Elastic) Client() *elastic.Client {
 }
 
 func (e *Elastic) Search(index string, query elastic.Query, pager *Pager, options *SearchOptions) *elastic.SearchResult {
+	if !e.engine.hasRequestCache {
+		return e.search(index, query, pager, options)
+	}
+	source, _ := query.Source()
+	key := fmt.Sprintf("%s %v %d %d", index, source, pager.CurrentPage, pager.PageSize)
+	if options != nil {
+		if options.sort != nil {
+			key += fmt.Sprintf(" %v %v", options.sort.fields, options.sort.asc)
+		}
+		names := make([]string, 0, len(options.aggregation))
+		for name := range options.aggregation {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			aggregationSource, _ := options.aggregation[name].Source()
+			key += fmt.Sprintf(" %s %v", name, aggregationSource)
+		}
+	}
+	result := requestCacheMemo(e.engine, requestCacheElasticPrefix+e.code, key, func() interface{} {
+		return e.search(index, query, pager, options)
+	}).(*elastic.SearchResult)
+	return copyElasticSearchResult(result)
+}
+
+func copyElasticSearchResult(result *elastic.SearchResult) *elastic.SearchResult {
+	copied := *result
+	if result.Hits != nil {
+		hits := *result.Hits
+		hits.Hits = make([]*elastic.SearchHit, len(result.Hits.Hits))
+		for i, hit := range result.Hits.Hits {
+			copiedHit := *hit
+			hits.Hits[i] = &copiedHit
+		}
+		copied.Hits = &hits
+	}
+	if result.Aggregations != nil {
+		copied.Aggregations = make(elastic.Aggregations, len(result.Aggregations))
+		for name, aggregation := range result.Aggregations {
+			copied.Aggregations[name] = aggregation
+		}
+	}
+	return &copied
+}
+
+func (e *Elastic) search(index string, query elastic.Query, pager *Pager, options *SearchOptions) *elastic.SearchResult {
 	start := time.Now()
 	searchService := e.client.Search().Query(query)
 	from := (pager.CurrentPage - 1) * pager.PageSize
@@ -6,9 +6,9 @@ import (
 	"os"
 	"reflect"
 	"sync"
+	"time"
 
 	"github.com/bsm/redislock"
-	"github.com/golang/groupcache/lru"
 
 	logApex "github.com/apex/log"
 
@@ -38,6 +38,7 @@ type Engine struct {
 	logMetaDataMutex          sync.RWMutex
 	dataLoader                *dataLoader
 	hasRequestCache           bool
+	requestCacheMutex         sync.Mutex
 	queryLoggers              map[QueryLoggerSource]*logger
 	hasRedisLogger            bool
 	hasStreamsLogger          bool
@@ -177,7 +178,7 @@ func (e *Engine) GetLocalCache(code ...string) *LocalCache {
 		val, has := e.registry.localCacheContainers[dbCode]
 		if !has {
 			if dbCode == requestCacheKey {
-				cache = &LocalCache{code: dbCode, engine: e, m: &sync.Mutex{}, lru: lru.New(5000)}
+				cache = &LocalCache{code: dbCode, engine: e, config: newLocalCacheConfig(dbCode, LocalCacheOptions{Size: 5000})}
 				if e.localCache == nil {
 					e.localCache = map[string]*LocalCache{dbCode: cache}
 				} else {
@@ -187,7 +188,7 @@ func (e *Engine) GetLocalCache(code ...string) *LocalCache {
 			}
 			panic(fmt.Errorf("unregistered local cache pool '%s'", dbCode))
 		}
-		cache = &LocalCache{engine: e, code: val.code, lru: val.lru, m: &val.m}
+		cache = &LocalCache{engine: e, code: val.code, config: val}
 		if e.localCache == nil {
 			e.localCache = map[string]*LocalCache{dbCode: cache}
 		} else {
@@ -210,11 +211,8 @@ func (e *Engine) GetRedis(code ...string) *RedisCache {
 		if !has {
 			panic(fmt.Errorf("unregistered redis cache pool '%s'", dbCode))
 		}
-		client := val.client
-		if client != nil {
-			client = client.WithContext(e.context)
-		}
-		cache = &RedisCache{engine: e, code: val.code, client: client, ctx: context.Background()}
+		cache = &RedisCache{engine: e, code: val.code, client: val.clientWithContext(e.context), cluster: val.cluster,
+			prefix: val.prefix, ctx: context.Background()}
 		if e.redis == nil {
 			e.redis = map[string]*RedisCache{dbCode: cache}
 		} else {
@@ -237,11 +235,8 @@ func (e *Engine) GetRedisSearch(code ...string) *RedisSearch {
 		if !has {
 			panic(fmt.Errorf("unregistered redis cache pool '%s'", dbCode))
 		}
-		client := val.client
-		if client != nil {
-			client = client.WithContext(e.context)
-		}
-		redisClient := &RedisCache{engine: e, code: val.code, client: client, ctx: context.Background()}
+		redisClient := &RedisCache{engine: e, code: val.code, client: val.clientWithContext(e.context), cluster: val.cluster,
+			prefix: val.prefix, ctx: context.Background()}
 		cache = &RedisSearch{engine: e, code: val.code, redis: redisClient, ctx: context.Background()}
 		if e.redisSearch == nil {
 			e.redisSearch = map[string]*RedisSearch{dbCode: cache}
@@ -312,7 +307,7 @@ func (e *Engine) GetLocker(code ...string) *Locker {
 			panic(fmt.Errorf("unregistered locker pool '%s'", dbCode))
 		}
 		lockerClient := &standardLockerClient{client: redislock.New(e.registry.redisServers[val].client)}
-		locker = &Locker{locker: lockerClient, code: val, engine: e}
+		locker = &Locker{locker: lockerClient, code: val, engine: e, prefix: e.registry.redisServers[val].prefix}
 		if e.locks == nil {
 			e.locks = map[string]*Locker{dbCode: locker}
 		} else {
@@ -399,6 +394,14 @@ func (e *Engine) GetRegistry() ValidatedRegistry {
 	return e.registry
 }
 
+func (e *Engine) GetCacheMetrics() []*CacheMetric {
+	return e.registry.cacheMetrics.get()
+}
+
+func (e *Engine) ResetCacheMetrics() {
+	e.registry.cacheMetrics.reset()
+}
+
 func (e *Engine) SearchWithCount(where *Where, pager *Pager, entities interface{}, references ...string) (totalRows int) {
 	return search(true, e, where, pager, true, reflect.ValueOf(entities).Elem(), references...)
 }
@@ -449,10 +452,39 @@ func (e *Engine) CachedSearchWithReferences(entities interface{}, indexName stri
 	return total
 }
 
+func (e *Engine) RebuildBloomFilter(entity Entity) {
+	rebuildBloomFilter(e, entity)
+}
+
+func (e *Engine) CachedSearchAdHoc(where *Where, pager *Pager, entities interface{}, ttl time.Duration) (totalRows int) {
+	return cachedSearchAdHoc(e, where, pager, entities, ttl)
+}
+
+func (e *Engine) CachedCount(entity Entity, indexName string, arguments ...interface{}) int {
+	return int(cachedAggregate(e, entity, indexName, arguments, false))
+}
+
+func (e *Engine) CachedSum(entity Entity, indexName string, arguments ...interface{}) float64 {
+	return cachedAggregate(e, entity, indexName, arguments, true)
+}
+
+func (e *Engine) WarmUpCache(entity Entity, where *Where, batchSize int, options ...*WarmUpOptions) (loaded int) {
+	return warmUpCache(e, entity, where, batchSize, firstWarmUpOptions(options))
+}
+
+func (e *Engine) WarmUpCachedSearch(entity Entity, indexName string, arguments []interface{}, batchSize int,
+	options ...*WarmUpOptions) (totalRows int) {
+	return warmUpCachedSearch(e, entity, indexName, arguments, batchSize, firstWarmUpOptions(options))
+}
+
 func (e *Engine) ClearByIDs(entity Entity, ids ...uint64) {
 	clearByIDs(e, entity, ids...)
 }
 
+func (e *Engine) UpdateFields(entity Entity, id uint64, fields Bind) {
+	updateFields(e, entity, id, fields)
+}
+
 func (e *Engine) LoadByID(id uint64, entity Entity, references ...string) (found bool) {
 	found, _, _ = loadByID(e, id, entity, true, true, references...)
 	return found
@@ -478,6 +510,10 @@ func (e *Engine) LoadByIDs(ids []uint64, entities interface{}, references ...str
 	return missing
 }
 
+func (e *Engine) LoadMulti(targets map[interface{}][]uint64) (missing map[interface{}][]uint64) {
+	return loadMulti(e, targets)
+}
+
 func (e *Engine) GetAlters() (alters []Alter) {
 	return getAlters(e)
 }
@@ -2,6 +2,7 @@ package orm
 
 import (
 	"strconv"
+	"strings"
 
 	"github.com/pkg/errors"
 )
@@ -19,7 +20,8 @@ func redisSearch(e *Engine, entity Entity, query *RedisSearchQuery, pager *Pager
 	totalRows, res := search.search(schema.redisSearchIndex.Name, query, pager, true)
 	ids := make([]uint64, len(res))
 	for i, v := range res {
-		ids[i], _ = strconv.ParseUint(v.(string)[6:], 10, 64)
+		key := v.(string)
+		ids[i], _ = strconv.ParseUint(key[strings.LastIndex(key, ":")+1:], 10, 64)
 	}
 	return ids, totalRows
 }
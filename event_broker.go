@@ -267,7 +267,6 @@ type eventsConsumer struct {
 	garbageTick            time.Duration
 	minIdle                time.Duration
 	claimDuration          time.Duration
-	garbageCollectorSha1   string
 	consumed               int
 	consumedMutex          sync.Mutex
 }
@@ -634,42 +633,8 @@ func (r *eventsConsumer) garbageCollector(engine *Engine, force bool) {
 			end = strconv.FormatInt(minID[0], 10) + "-" + strconv.FormatInt(minID[1], 10)
 		}
 
-		if r.garbageCollectorSha1 == "" {
-			sha1, has := redisGarbage.Get("_orm_gc_sha1")
-			if !has {
-				script := `
-						local count = 0
-						local all = 0
-						while(true)
-						do
-							local T = redis.call('XRANGE', KEYS[1], "-", ARGV[1], "COUNT", 1000)
-							local ids = {}
-							for _, v in pairs(T) do
-								table.insert(ids, v[1])
-								count = count + 1
-							end
-							if table.getn(ids) > 0 then
-								redis.call('XDEL', KEYS[1], unpack(ids))
-							end
-							if table.getn(ids) < 1000 then
-								all = 1
-								break
-							end
-							if count >= 100000 then
-								break
-							end
-						end
-						return all
-						`
-				r.garbageCollectorSha1 = redisGarbage.ScriptLoad(script)
-				redisGarbage.Set("_orm_gc_sha1", r.garbageCollectorSha1, 604800)
-			} else {
-				r.garbageCollectorSha1 = sha1
-			}
-		}
-
 		for {
-			res := redisGarbage.EvalSha(r.garbageCollectorSha1, []string{stream}, end)
+			res := redisGarbage.RunScript(redisGarbageCollectorScript, []string{redisStreamKey(redisGarbage.cluster, stream)}, end)
 			if res == int64(1) {
 				break
 			}
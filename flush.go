@@ -50,6 +50,7 @@ func flush(engine *Engine, updateSQLs map[string][]string, deleteBinds map[refle
 	dataLoaderSets := make(map[*tableSchema]map[uint64][]interface{})
 	localCacheDeletes := make(map[string]map[string]bool)
 	lazyMap := make(map[string]interface{})
+	var upserts map[string]*upsertGroup
 	rFlusher := engine.afterCommitRedisFlusher
 	if rFlusher == nil {
 		rFlusher = &redisFlusher{engine: engine}
@@ -61,6 +62,7 @@ func flush(engine *Engine, updateSQLs map[string][]string, deleteBinds map[refle
 	for _, entity := range entities {
 		initIfNeeded(engine, entity).initDBData()
 		schema := entity.getORM().tableSchema
+		clearRequestCacheSearches(engine, schema)
 		if !isInTransaction && schema.GetMysql(engine).inTransaction {
 			isInTransaction = true
 		}
@@ -119,6 +121,16 @@ func flush(engine *Engine, updateSQLs map[string][]string, deleteBinds map[refle
 			}
 			deleteBinds[t][currentID] = dbData
 		} else if !orm.inDB {
+			if orm.upsertIndex != "" {
+				if currentID > 0 {
+					bind["ID"] = currentID
+				}
+				if upserts == nil {
+					upserts = make(map[string]*upsertGroup)
+				}
+				addToUpsertGroup(upserts, schema, orm, bind, entity)
+				continue
+			}
 			onUpdate := entity.getORM().onDuplicateKeyUpdate
 			if onUpdate != nil {
 				if lazy {
@@ -239,16 +251,16 @@ func flush(engine *Engine, updateSQLs map[string][]string, deleteBinds map[refle
 			/* #nosec */
 			sql := "UPDATE " + schema.GetTableName() + " SET " + strings.Join(fields, ",") + " WHERE `ID` = " + strconv.FormatUint(currentID, 10)
 			db := schema.GetMysql(engine)
-			if lazy {
-				fillLazyQuery(lazyMap, db.GetPoolCode(), sql, nil)
-			} else {
+			if lazy && !schema.writeBehind {
+				fillLazyQuery(lazyMap, db.GetPoolCode(), sql, nil, "")
+			} else if !schema.writeBehind {
 				smartUpdate := false
 				if smart && !db.inTransaction && schema.hasLocalCache && !schema.hasRedisCache {
 					keys := getCacheQueriesKeys(schema, bind, dbData, false)
 					smartUpdate = len(keys) == 0
 				}
 				if smartUpdate {
-					fillLazyQuery(lazyMap, db.GetPoolCode(), sql, nil)
+					fillLazyQuery(lazyMap, db.GetPoolCode(), sql, nil, "")
 				} else {
 					if updateSQLs == nil {
 						updateSQLs = make(map[string][]string)
@@ -256,8 +268,11 @@ func flush(engine *Engine, updateSQLs map[string][]string, deleteBinds map[refle
 					updateSQLs[schema.mysqlPoolName] = append(updateSQLs[schema.mysqlPoolName], sql)
 				}
 			}
-			updateCacheAfterUpdate(lazy, dbData, engine, entity, bind, schema, localCacheSets, localCacheDeletes, db, currentID,
-				rFlusher, dataLoaderSets)
+			updateCacheAfterUpdate(lazy && !schema.writeBehind, dbData, engine, entity, bind, schema, localCacheSets, localCacheDeletes,
+				db, currentID, rFlusher, dataLoaderSets)
+			if schema.writeBehind {
+				addWriteBehind(engine, schema, rFlusher, currentID, entity)
+			}
 		}
 	}
 
@@ -296,7 +311,7 @@ func flush(engine *Engine, updateSQLs map[string][]string, deleteBinds map[refle
 		id := uint64(0)
 		db := schema.GetMysql(engine)
 		if lazy {
-			fillLazyQuery(lazyMap, db.GetPoolCode(), sql, insertArguments[typeOf])
+			fillLazyQuery(lazyMap, db.GetPoolCode(), sql, insertArguments[typeOf], schema.t.String())
 		} else {
 			res := db.Exec(sql, insertArguments[typeOf]...)
 			id = res.LastInsertId()
@@ -316,6 +331,18 @@ func flush(engine *Engine, updateSQLs map[string][]string, deleteBinds map[refle
 				rFlusher, dataLoaderSets)
 		}
 	}
+	for _, group := range upserts {
+		for _, entity := range group.entities {
+			orm := entity.getORM()
+			orm.upsertIndex = ""
+			orm.upsertFields = nil
+		}
+		if lazy {
+			fillLazyUpsert(lazyMap, group)
+		} else {
+			flushUpsert(engine, group, localCacheSets, localCacheDeletes, rFlusher, dataLoaderSets)
+		}
+	}
 	if root {
 		for pool, queries := range updateSQLs {
 			db := engine.GetMysql(pool)
@@ -338,7 +365,7 @@ func flush(engine *Engine, updateSQLs map[string][]string, deleteBinds map[refle
 			sql := "DELETE FROM `" + schema.tableName + "` WHERE " + NewWhere("`ID` IN ?", ids).String()
 			db := schema.GetMysql(engine)
 			if lazy {
-				fillLazyQuery(lazyMap, db.GetPoolCode(), sql, ids)
+				fillLazyQuery(lazyMap, db.GetPoolCode(), sql, ids, "")
 			} else {
 				usage := schema.GetUsage(engine.registry)
 				if len(usage) > 0 {
@@ -387,6 +414,7 @@ func flush(engine *Engine, updateSQLs map[string][]string, deleteBinds map[refle
 					addLocalCacheSet(localCacheSets, db.GetPoolCode(), localCache.code, schema.getCacheKey(id), "nil")
 					keys := getCacheQueriesKeys(schema, bind, dbData, true)
 					addLocalCacheDeletes(localCacheDeletes, localCache.code, keys...)
+					addLocalCacheDeletes(localCacheDeletes, localCache.code, getAdHocSearchTagsKeys(schema, bind, true)...)
 				} else if engine.dataLoader != nil {
 					addToDataLoader(dataLoaderSets, schema, id, nil)
 				}
@@ -394,6 +422,8 @@ func flush(engine *Engine, updateSQLs map[string][]string, deleteBinds map[refle
 					rFlusher.Del(redisCache.code, schema.getCacheKey(id))
 					keys := getCacheQueriesKeys(schema, bind, dbData, true)
 					rFlusher.Del(redisCache.code, keys...)
+					rFlusher.Del(redisCache.code, getAdHocSearchTagsKeys(schema, bind, true)...)
+					addCachedAggregatesDeltas(schema, rFlusher, bind, dbData, nil)
 				}
 				if schema.hasSearchCache {
 					key := schema.redisSearchPrefix + strconv.FormatUint(id, 10)
@@ -407,6 +437,7 @@ func flush(engine *Engine, updateSQLs map[string][]string, deleteBinds map[refle
 			cache := engine.GetLocalCache(cacheCode)
 			if !isInTransaction {
 				cache.MSet(keys...)
+				publishLocalCacheSetsInvalidation(engine, cacheCode, keys)
 			} else {
 				if engine.afterCommitLocalCacheSets == nil {
 					engine.afterCommitLocalCacheSets = make(map[string][]interface{})
@@ -431,6 +462,7 @@ func flush(engine *Engine, updateSQLs map[string][]string, deleteBinds map[refle
 			deletesLocalCache.(map[string][]string)[cacheCode] = keys
 		} else {
 			engine.GetLocalCache(cacheCode).Remove(keys...)
+			publishLocalCacheInvalidation(engine, cacheCode, keys...)
 		}
 	}
 	if lazy {
@@ -490,21 +522,29 @@ func updateCacheAfterUpdate(lazy bool, dbData []interface{}, engine *Engine, ent
 		if lazy {
 			addLocalCacheDeletes(localCacheDeletes, localCache.code, cacheKey)
 		}
-		keys := getCacheQueriesKeys(schema, bind, dbData, false)
-		addLocalCacheDeletes(localCacheDeletes, localCache.code, keys...)
-		keys = getCacheQueriesKeys(schema, bind, old, false)
-		addLocalCacheDeletes(localCacheDeletes, localCache.code, keys...)
+		if !schema.writeBehind {
+			keys := getCacheQueriesKeys(schema, bind, dbData, false)
+			addLocalCacheDeletes(localCacheDeletes, localCache.code, keys...)
+			keys = getCacheQueriesKeys(schema, bind, old, false)
+			addLocalCacheDeletes(localCacheDeletes, localCache.code, keys...)
+			addLocalCacheDeletes(localCacheDeletes, localCache.code, getAdHocSearchTagsKeys(schema, bind, false)...)
+		}
 	} else if engine.dataLoader != nil {
 		addToDataLoader(dataLoaderSets, schema, currentID, buildLocalCacheValue(entity))
 	}
+	fillRedisSearchFromBind(schema, redisFlusher, bind, entity.GetID())
+	if schema.writeBehind {
+		return
+	}
 	if hasRedis {
 		redisFlusher.Del(redisCache.code, schema.getCacheKey(currentID))
 		keys := getCacheQueriesKeys(schema, bind, dbData, false)
 		redisFlusher.Del(redisCache.code, keys...)
 		keys = getCacheQueriesKeys(schema, bind, old, false)
 		redisFlusher.Del(redisCache.code, keys...)
+		redisFlusher.Del(redisCache.code, getAdHocSearchTagsKeys(schema, bind, false)...)
+		addCachedAggregatesDeltas(schema, redisFlusher, bind, old, dbData)
 	}
-	fillRedisSearchFromBind(schema, redisFlusher, bind, entity.GetID())
 	addDirtyQueues(redisFlusher, bind, schema, currentID, "u")
 	addToLogQueue(engine, redisFlusher, schema, currentID, convertDBDataToMap(schema, old), bind, entity.getORM().logMeta)
 }
@@ -1031,6 +1071,17 @@ func getCacheQueriesKeys(schema *tableSchema, bind map[string]interface{}, data
 		if addedDeleted && len(definition.TrackedFields) == 0 {
 			keys = append(keys, getCacheKeySearch(schema, indexName))
 		}
+		if len(definition.RangeArguments) > 0 {
+			changed := addedDeleted
+			for _, trackedField := range definition.TrackedFields {
+				_, has := bind[trackedField]
+				changed = changed || has
+			}
+			if changed {
+				keys = append(keys, getCacheKeyGeneration(schema, indexName))
+			}
+			continue
+		}
 		for _, trackedField := range definition.TrackedFields {
 			_, has := bind[trackedField]
 			if has {
@@ -1126,7 +1177,7 @@ func addToLogQueue(engine *Engine, redisFlusher RedisFlusher, tableSchema *table
 	redisFlusher.Publish(logChannelName, val)
 }
 
-func fillLazyQuery(lazyMap map[string]interface{}, dbCode string, sql string, values []interface{}) {
+func fillLazyQuery(lazyMap map[string]interface{}, dbCode string, sql string, values []interface{}, insertedEntity string) {
 	updatesMap := lazyMap["q"]
 	if updatesMap == nil {
 		updatesMap = make([]interface{}, 0)
@@ -1136,6 +1187,9 @@ func fillLazyQuery(lazyMap map[string]interface{}, dbCode string, sql string, va
 	lazyValue[0] = dbCode
 	lazyValue[1] = sql
 	lazyValue[2] = values
+	if insertedEntity != "" {
+		lazyValue = append(lazyValue, insertedEntity)
+	}
 	lazyMap["q"] = append(updatesMap.([]interface{}), lazyValue)
 }
 
@@ -1176,6 +1230,7 @@ func updateCacheForInserted(engine *Engine, entity Entity, lazy bool, id uint64,
 		}
 		keys := getCacheQueriesKeys(schema, bind, entity.getORM().dBData, true)
 		addLocalCacheDeletes(localCacheDeletes, localCache.code, keys...)
+		addLocalCacheDeletes(localCacheDeletes, localCache.code, getAdHocSearchTagsKeys(schema, bind, true)...)
 	} else if !lazy && engine.dataLoader != nil {
 		addToDataLoader(dataLoaderSets, schema, id, buildLocalCacheValue(entity))
 	}
@@ -1184,8 +1239,11 @@ func updateCacheForInserted(engine *Engine, entity Entity, lazy bool, id uint64,
 		redisFlusher.Del(redisCache.code, schema.getCacheKey(id))
 		keys := getCacheQueriesKeys(schema, bind, entity.getORM().dBData, true)
 		redisFlusher.Del(redisCache.code, keys...)
+		redisFlusher.Del(redisCache.code, getAdHocSearchTagsKeys(schema, bind, true)...)
+		addCachedAggregatesDeltas(schema, redisFlusher, bind, nil, entity.getORM().dBData)
 	}
 	fillRedisSearchFromBind(schema, redisFlusher, bind, id)
+	addToBloomFilter(schema, redisFlusher, id)
 
 	addDirtyQueues(redisFlusher, bind, schema, id, "i")
 	addToLogQueue(engine, redisFlusher, schema, id, nil, bind, entity.getORM().logMeta)
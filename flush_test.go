@@ -2,12 +2,14 @@ package orm
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
 	"testing"
 	"time"
 
 	apexLog "github.com/apex/log"
 	"github.com/apex/log/handlers/memory"
+	"github.com/go-sql-driver/mysql"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -629,3 +631,83 @@ func testFlush(t *testing.T, local bool, redis bool) {
 	assert.Equal(t, "Adam2", entitiesRefs[1].Name)
 	assert.Equal(t, "Adam Junior2", entitiesRefs[2].Name)
 }
+
+type flushRetryEntity struct {
+	ORM  `orm:"localCache;redisCache"`
+	ID   uint
+	Name string
+}
+
+type flushRetrySQLClient struct {
+	sqlClient
+	failures int
+}
+
+func (c *flushRetrySQLClient) Exec(query string, args ...interface{}) (sql.Result, error) {
+	if c.failures > 0 {
+		c.failures--
+		return nil, &mysql.MySQLError{Number: 1213, Message: "Deadlock found when trying to get lock; try restarting transaction"}
+	}
+	return c.sqlClient.Exec(query, args...)
+}
+
+func TestFlushRetry(t *testing.T) {
+	var entity *flushRetryEntity
+	engine := PrepareTables(t, &Registry{}, 5, entity)
+	db := engine.GetMysql()
+	client := &flushRetrySQLClient{sqlClient: db.client}
+	db.client = client
+	testLogger := memory.New()
+	engine.AddQueryLogger(testLogger, apexLog.WarnLevel, QueryLoggerSourceDB)
+
+	client.failures = 1
+	entity = &flushRetryEntity{Name: "John"}
+	assert.PanicsWithError(t, "Error 1213: Deadlock found when trying to get lock; try restarting transaction", func() {
+		engine.NewFlusher().Track(entity).FlushInTransaction()
+	})
+	assert.Equal(t, uint(0), entity.ID)
+	assert.Len(t, testLogger.Entries, 1)
+	assert.Equal(t, "[ORM][MYSQL][EXEC]", testLogger.Entries[0].Message)
+
+	client.failures = 2
+	entity = &flushRetryEntity{Name: "John"}
+	engine.NewFlusher().Track(entity).SetRetryPolicy(3, time.Millisecond).FlushInTransaction()
+	assert.Equal(t, uint(1), entity.ID)
+	assert.Len(t, testLogger.Entries, 5)
+	assert.Equal(t, "[ORM][MYSQL][RETRY]", testLogger.Entries[2].Message)
+	assert.Equal(t, 1, testLogger.Entries[2].Fields["attempt"])
+	assert.Equal(t, "[ORM][MYSQL][RETRY]", testLogger.Entries[4].Message)
+	assert.Equal(t, 2, testLogger.Entries[4].Fields["attempt"])
+	entity = &flushRetryEntity{}
+	assert.True(t, engine.LoadByID(1, entity))
+	assert.Equal(t, "John", entity.Name)
+
+	client.failures = 3
+	entity.Name = "Tom"
+	assert.PanicsWithError(t, "Error 1213: Deadlock found when trying to get lock; try restarting transaction", func() {
+		engine.NewFlusher().Track(entity).SetRetryPolicy(3, 0).FlushInTransaction()
+	})
+	assert.Equal(t, "Tom", entity.Name)
+	assert.True(t, entity.IsDirty())
+	entity = &flushRetryEntity{}
+	assert.True(t, engine.LoadByID(1, entity))
+	assert.Equal(t, "John", entity.Name)
+}
+
+func TestFlushRetryUpsert(t *testing.T) {
+	var entity *upsertEntity
+	engine := PrepareTables(t, &Registry{}, 5, entity)
+	engine.Flush(&upsertEntity{Name: "John", Age: 18})
+	db := engine.GetMysql()
+	client := &flushRetrySQLClient{sqlClient: db.client}
+	db.client = client
+
+	client.failures = 1
+	entity = &upsertEntity{Name: "John", Age: 20}
+	engine.NewFlusher().Upsert(entity, "name", "Age").SetRetryPolicy(2, 0).FlushInTransaction()
+	assert.Equal(t, uint(1), entity.ID)
+	assert.Equal(t, 20, entity.Age)
+	entity = &upsertEntity{}
+	assert.True(t, engine.LoadByID(1, entity))
+	assert.Equal(t, 20, entity.Age)
+}
@@ -2,14 +2,18 @@ package orm
 
 import (
 	"fmt"
+	"reflect"
 	"sync"
 	"time"
 
+	log2 "github.com/apex/log"
+	"github.com/go-sql-driver/mysql"
 	"github.com/pkg/errors"
 )
 
 type Flusher interface {
 	Track(entity ...Entity) Flusher
+	Upsert(entity Entity, conflictIndex string, updateFields ...string) Flusher
 	Flush()
 	FlushWithCheck() error
 	FlushInTransactionWithCheck() error
@@ -22,15 +26,31 @@ type Flusher interface {
 	MarkDirty(entity Entity, queueCode string, ids ...uint64)
 	Delete(entity ...Entity) Flusher
 	ForceDelete(entity ...Entity) Flusher
+	SetRetryPolicy(attempts int, backoff time.Duration) Flusher
 }
 
 type flusher struct {
 	engine                 *Engine
 	trackedEntities        []Entity
 	trackedEntitiesCounter int
+	retryAttempts          int
+	retryBackoff           time.Duration
 	mutex                  sync.Mutex
 }
 
+type entitySnapshot struct {
+	entity               Entity
+	value                reflect.Value
+	dBData               []interface{}
+	onDuplicateKeyUpdate map[string]interface{}
+	upsertIndex          string
+	upsertFields         []string
+	loaded               bool
+	inDB                 bool
+	delete               bool
+	logMeta              map[string]interface{}
+}
+
 func (f *flusher) Track(entity ...Entity) Flusher {
 	f.mutex.Lock()
 	defer f.mutex.Unlock()
@@ -49,6 +69,15 @@ func (f *flusher) Track(entity ...Entity) Flusher {
 	return f
 }
 
+func (f *flusher) Upsert(entity Entity, conflictIndex string, updateFields ...string) Flusher {
+	orm := initIfNeeded(f.engine, entity)
+	validateUpsert(orm.tableSchema, conflictIndex, updateFields)
+	orm.upsertIndex = conflictIndex
+	orm.upsertFields = updateFields
+	f.Track(entity)
+	return f
+}
+
 func (f *flusher) Delete(entity ...Entity) Flusher {
 	for _, e := range entity {
 		e.markToDelete()
@@ -65,6 +94,12 @@ func (f *flusher) ForceDelete(entity ...Entity) Flusher {
 	return f
 }
 
+func (f *flusher) SetRetryPolicy(attempts int, backoff time.Duration) Flusher {
+	f.retryAttempts = attempts
+	f.retryBackoff = backoff
+	return f
+}
+
 func (f *flusher) Flush() {
 	f.flushTrackedEntities(false, false, true)
 }
@@ -130,6 +165,30 @@ func (f *flusher) flushTrackedEntities(lazy bool, transaction bool, smart bool)
 	}
 	f.mutex.Lock()
 	defer f.mutex.Unlock()
+	if !transaction || f.retryAttempts <= 0 {
+		f.runFlush(lazy, transaction, smart)
+		return
+	}
+	snapshots := f.snapshotEntities()
+	for attempt := 1; ; attempt++ {
+		err := f.tryFlushInTransaction(lazy, smart)
+		if err == nil {
+			return
+		}
+		if attempt >= f.retryAttempts {
+			panic(err)
+		}
+		f.logRetry(attempt, err)
+		for _, snapshot := range snapshots {
+			snapshot.restore()
+		}
+		if f.retryBackoff > 0 {
+			time.Sleep(f.retryBackoff * time.Duration(1<<uint(attempt-1)))
+		}
+	}
+}
+
+func (f *flusher) runFlush(lazy bool, transaction bool, smart bool) {
 	var dbPools map[string]*DB
 	if transaction {
 		dbPools = make(map[string]*DB)
@@ -154,6 +213,100 @@ func (f *flusher) flushTrackedEntities(lazy bool, transaction bool, smart bool)
 	}
 }
 
+func (f *flusher) tryFlushInTransaction(lazy bool, smart bool) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			asErr, is := r.(error)
+			if !is || !isRetryableError(asErr) {
+				panic(r)
+			}
+			err = asErr
+		}
+	}()
+	f.runFlush(lazy, true, smart)
+	return nil
+}
+
+func (f *flusher) snapshotEntities() []*entitySnapshot {
+	snapshots := make([]*entitySnapshot, 0, len(f.trackedEntities))
+	visited := make(map[Entity]bool)
+	var add func(entity Entity)
+	add = func(entity Entity) {
+		if visited[entity] {
+			return
+		}
+		visited[entity] = true
+		orm := initIfNeeded(f.engine, entity)
+		snapshot := &entitySnapshot{entity: entity, value: reflect.New(orm.elem.Type()).Elem(), onDuplicateKeyUpdate: orm.onDuplicateKeyUpdate,
+			upsertIndex: orm.upsertIndex, upsertFields: orm.upsertFields, loaded: orm.loaded, inDB: orm.inDB, delete: orm.delete, logMeta: orm.logMeta}
+		snapshot.value.Set(orm.elem)
+		if orm.dBData != nil {
+			snapshot.dBData = make([]interface{}, len(orm.dBData))
+			copy(snapshot.dBData, orm.dBData)
+		}
+		snapshots = append(snapshots, snapshot)
+		for _, refName := range orm.tableSchema.refOne {
+			refValue := orm.elem.FieldByName(refName)
+			if refValue.IsValid() && !refValue.IsNil() {
+				refEntity := refValue.Interface().(Entity)
+				if refEntity.GetID() == 0 {
+					add(refEntity)
+				}
+			}
+		}
+		for _, refName := range orm.tableSchema.refMany {
+			refValue := orm.elem.FieldByName(refName)
+			if refValue.IsValid() && !refValue.IsNil() {
+				for i := 0; i < refValue.Len(); i++ {
+					refEntity := refValue.Index(i).Interface().(Entity)
+					if refEntity.GetID() == 0 {
+						add(refEntity)
+					}
+				}
+			}
+		}
+	}
+	for _, entity := range f.trackedEntities {
+		add(entity)
+	}
+	return snapshots
+}
+
+func (s *entitySnapshot) restore() {
+	orm := s.entity.getORM()
+	orm.elem.Set(s.value)
+	orm.onDuplicateKeyUpdate = s.onDuplicateKeyUpdate
+	orm.upsertIndex = s.upsertIndex
+	orm.upsertFields = s.upsertFields
+	orm.loaded = s.loaded
+	orm.inDB = s.inDB
+	orm.delete = s.delete
+	orm.logMeta = s.logMeta
+	orm.dBData = nil
+	if s.dBData != nil {
+		orm.dBData = make([]interface{}, len(s.dBData))
+		copy(orm.dBData, s.dBData)
+	}
+}
+
+func (f *flusher) logRetry(attempt int, err error) {
+	if !f.engine.hasDBLogger {
+		return
+	}
+	e := f.engine.queryLoggers[QueryLoggerSourceDB].log.WithFields(log2.Fields{
+		"attempt":  attempt,
+		"attempts": f.retryAttempts,
+		"target":   "mysql",
+		"type":     "retry",
+	})
+	injectLogError(err, e).Warn("[ORM][MYSQL][RETRY]")
+}
+
+func isRetryableError(err error) bool {
+	sqlErr, is := err.(*mysql.MySQLError)
+	return is && (sqlErr.Number == 1213 || sqlErr.Number == 1205)
+}
+
 func (f *flusher) flushWithCheck(transaction bool) error {
 	var err error
 	func() {
@@ -3,8 +3,7 @@ package orm
 import (
 	"fmt"
 	"reflect"
-
-	jsoniter "github.com/json-iterator/go"
+	"time"
 )
 
 func loadByID(engine *Engine, id uint64, entity Entity, fillStruct bool, useCache bool, references ...string) (found bool, data []interface{}, schema *tableSchema) {
@@ -35,6 +34,9 @@ func loadByID(engine *Engine, id uint64, entity Entity, fillStruct bool, useCach
 
 	var cacheKey string
 	if useCache {
+		if len(filterByBloomFilter(engine, schema, []uint64{id})) == 0 {
+			return false, nil, schema
+		}
 		if !hasLocalCache && engine.hasRequestCache {
 			hasLocalCache = true
 			localCache = engine.GetLocalCache(requestCacheKey)
@@ -43,6 +45,7 @@ func loadByID(engine *Engine, id uint64, entity Entity, fillStruct bool, useCach
 		if hasLocalCache {
 			cacheKey = schema.getCacheKey(id)
 			e, has := localCache.Get(cacheKey)
+			engine.registry.cacheMetrics.add(schema, "", localCacheLayer(localCache), boolToInt(has), boolToInt(!has))
 			if has {
 				if e == "nil" {
 					return false, nil, schema
@@ -62,13 +65,12 @@ func loadByID(engine *Engine, id uint64, entity Entity, fillStruct bool, useCach
 		if hasRedis {
 			cacheKey = schema.getCacheKey(id)
 			row, has := redisCache.Get(cacheKey)
+			engine.registry.cacheMetrics.add(schema, "", CacheLayerRedis, boolToInt(has), boolToInt(!has))
 			if has {
 				if row == "nil" {
 					return false, nil, schema
 				}
-				decoded := make([]interface{}, len(schema.columnNames))
-				_ = jsoniter.ConfigFastest.Unmarshal([]byte(row), &decoded)
-				convertDataFromJSON(schema.fields, 0, decoded)
+				decoded := decodeRedisRow(engine.registry, schema, row)
 				if fillStruct {
 					fillFromDBRow(id, engine, decoded, entity, false)
 				} else {
@@ -82,13 +84,22 @@ func loadByID(engine *Engine, id uint64, entity Entity, fillStruct bool, useCach
 		}
 	}
 
-	found, data = searchRow(false, fillStruct, engine, NewWhere("`ID` = ?", id), entity, nil)
+	fresh := true
+	if cacheKey != "" && !schema.GetMysql(engine).inTransaction {
+		found, data, fresh = loadByIDFromDBProtected(engine, schema, id, cacheKey, redisCache, entity)
+		if found && fillStruct {
+			fillFromDBRow(id, engine, data, entity, true)
+		}
+	} else {
+		found, data = searchRow(false, fillStruct, engine, NewWhere("`ID` = ?", id), entity, nil)
+	}
+	engine.registry.cacheMetrics.add(schema, "", CacheLayerDB, boolToInt(found), boolToInt(!found))
 	if !found {
 		if localCache != nil {
 			localCache.Set(cacheKey, "nil")
 		}
-		if redisCache != nil {
-			redisCache.Set(cacheKey, "nil", 60)
+		if redisCache != nil && fresh {
+			redisCache.Set(cacheKey, "nil", schema.redisNilTTL())
 		}
 		return false, nil, schema
 	}
@@ -96,8 +107,12 @@ func loadByID(engine *Engine, id uint64, entity Entity, fillStruct bool, useCach
 		if localCache != nil {
 			localCache.Set(cacheKey, buildLocalCacheValue(entity))
 		}
-		if redisCache != nil {
-			redisCache.Set(cacheKey, buildRedisValue(entity), 0)
+		if redisCache != nil && fresh {
+			if schema.writeBehind {
+				redisCache.mSetWithTTL(redisSetNX, cacheKey, buildRedisValue(entity))
+			} else {
+				redisCache.Set(cacheKey, buildRedisValue(entity), int(schema.redisCacheTTL/time.Second))
+			}
 		}
 	}
 
@@ -109,9 +124,40 @@ func loadByID(engine *Engine, id uint64, entity Entity, fillStruct bool, useCach
 	return true, data, schema
 }
 
+func loadByIDFromDBProtected(engine *Engine, schema *tableSchema, id uint64, cacheKey string, redisCache *RedisCache,
+	entity Entity) (found bool, data []interface{}, fresh bool) {
+	var check func() (interface{}, bool)
+	if redisCache != nil {
+		check = func() (interface{}, bool) {
+			row, has := redisCache.Get(cacheKey)
+			if !has {
+				return nil, false
+			}
+			if row == "nil" {
+				return []interface{}(nil), true
+			}
+			decoded := decodeRedisRow(engine.registry, schema, row)
+			return decoded, true
+		}
+	}
+	value, fresh := engine.registry.stampedeProtection.do(engine, cacheKey, check, func() interface{} {
+		found, row := searchRow(false, false, engine, NewWhere("`ID` = ?", id), entity, nil)
+		if !found {
+			return []interface{}(nil)
+		}
+		return row
+	})
+	row := value.([]interface{})
+	if row == nil {
+		return false, nil, fresh
+	}
+	data = make([]interface{}, len(row))
+	copy(data, row)
+	return true, data, fresh
+}
+
 func buildRedisValue(entity Entity) string {
-	encoded, _ := jsoniter.ConfigFastest.Marshal(buildLocalCacheValue(entity))
-	return string(encoded)
+	return encodeRedisRow(entity.getORM().tableSchema.redisCodec, buildLocalCacheValue(entity))
 }
 
 func buildLocalCacheValue(entity Entity) []interface{} {
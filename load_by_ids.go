@@ -3,11 +3,17 @@ package orm
 import (
 	"fmt"
 	"reflect"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 
-	jsoniter "github.com/json-iterator/go"
+	"github.com/segmentio/fasthash/fnv1a"
 )
 
+const warmUpReferencesMaxGoroutines = 10
+
 func tryByIDs(engine *Engine, ids []uint64, fillStruct bool, entities reflect.Value, references []string) (missing []uint64, schema *tableSchema) {
 	missing = make([]uint64, 0)
 	valOrigin := entities
@@ -47,6 +53,8 @@ func tryByIDs(engine *Engine, ids []uint64, fillStruct bool, entities reflect.Va
 		return
 	}
 
+	ids = filterByBloomFilter(engine, schema, ids)
+	lenIDs = len(ids)
 	var localCacheKeys []string
 	var redisCacheKeys []string
 	results := make(map[string]Entity, lenIDs)
@@ -70,11 +78,13 @@ func tryByIDs(engine *Engine, ids []uint64, fillStruct bool, entities reflect.Va
 		if hasLocalCache {
 			resultsLocalCache := localCache.MGet(cacheKeys...)
 			cacheKeys = getKeysForNils(engine, schema, resultsLocalCache, keysMapping, results, false)
+			engine.registry.cacheMetrics.add(schema, "", localCacheLayer(localCache), len(resultsLocalCache)-len(cacheKeys), len(cacheKeys))
 			localCacheKeys = cacheKeys
 		}
 		if hasRedis && len(cacheKeys) > 0 {
 			resultsRedis := redisCache.MGet(cacheKeys...)
 			cacheKeys = getKeysForNils(engine, schema, resultsRedis, keysMapping, results, true)
+			engine.registry.cacheMetrics.add(schema, "", CacheLayerRedis, len(resultsRedis)-len(cacheKeys), len(cacheKeys))
 			redisCacheKeys = cacheKeys
 		}
 		ids = make([]uint64, len(cacheKeys))
@@ -84,10 +94,29 @@ func tryByIDs(engine *Engine, ids []uint64, fillStruct bool, entities reflect.Va
 	}
 	l := len(ids)
 	if l > 0 {
-		_ = search(false, engine, NewWhere("`ID` IN ?", ids), NewPager(1, l), false, entities)
-		for i := 0; i < entities.Len(); i++ {
-			e := entities.Index(i).Interface().(Entity)
-			results[schema.getCacheKey(e.GetID())] = e
+		if (hasLocalCache || hasRedis) && !schema.GetMysql(engine).inTransaction {
+			if !hasRedis {
+				redisCache = nil
+			}
+			rows, fresh := tryByIDsFromDBProtected(engine, schema, ids, redisCache)
+			for id, row := range rows {
+				data := make([]interface{}, len(row))
+				copy(data, row)
+				entity := reflect.New(schema.t).Interface().(Entity)
+				fillFromDBRow(id, engine, data, entity, true)
+				results[schema.getCacheKey(id)] = entity
+			}
+			if !fresh {
+				redisCacheKeys = nil
+			}
+			engine.registry.cacheMetrics.add(schema, "", CacheLayerDB, len(rows), l-len(rows))
+		} else {
+			_ = search(false, engine, NewWhere("`ID` IN ?", ids), NewPager(1, l), false, entities)
+			for i := 0; i < entities.Len(); i++ {
+				e := entities.Index(i).Interface().(Entity)
+				results[schema.getCacheKey(e.GetID())] = e
+			}
+			engine.registry.cacheMetrics.add(schema, "", CacheLayerDB, entities.Len(), l-entities.Len())
 		}
 	}
 	if hasLocalCache {
@@ -128,7 +157,7 @@ func tryByIDs(engine *Engine, ids []uint64, fillStruct bool, entities reflect.Va
 				pairs[i+1] = toSet
 				i += 2
 			}
-			redisCache.MSet(pairs...)
+			redisCache.mSetWithTTL(schema.redisSetTTL(), pairs...)
 		}
 	}
 
@@ -151,6 +180,58 @@ func tryByIDs(engine *Engine, ids []uint64, fillStruct bool, entities reflect.Va
 	return
 }
 
+func tryByIDsFromDBProtected(engine *Engine, schema *tableSchema, ids []uint64, redisCache *RedisCache) (rows map[uint64][]interface{}, fresh bool) {
+	sorted := make([]uint64, len(ids))
+	copy(sorted, ids)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i] < sorted[j]
+	})
+	q := make([]string, len(sorted))
+	for i, id := range sorted {
+		q[i] = strconv.FormatUint(id, 10)
+	}
+	joined := strings.Join(q, ",")
+	key := schema.cachePrefix + ":ids:" + strconv.FormatUint(fnv1a.HashString64(joined), 10)
+	var check func() (interface{}, bool)
+	if redisCache != nil {
+		check = func() (interface{}, bool) {
+			cacheKeys := make([]string, len(sorted))
+			for i, id := range sorted {
+				cacheKeys[i] = schema.getCacheKey(id)
+			}
+			fromRedis := redisCache.MGet(cacheKeys...)
+			found := make(map[uint64][]interface{}, len(sorted))
+			for i, id := range sorted {
+				value := fromRedis[cacheKeys[i]]
+				if value == nil {
+					return nil, false
+				}
+				if value != "nil" {
+					decoded := decodeRedisRow(engine.registry, schema, value.(string))
+					found[id] = decoded
+				}
+			}
+			return found, true
+		}
+	}
+	value, fresh := engine.registry.stampedeProtection.do(engine, key, check, func() interface{} {
+		/* #nosec */
+		query := "SELECT " + schema.fieldsQuery + " FROM `" + schema.tableName + "` WHERE `ID` IN (" + joined + ")"
+		results, def := schema.GetMysql(engine).Query(query)
+		defer def()
+		found := make(map[uint64][]interface{}, len(sorted))
+		for results.Next() {
+			pointers := prepareScan(schema)
+			results.Scan(pointers...)
+			convertScan(schema.fields, 0, pointers)
+			found[pointers[0].(uint64)] = pointers
+		}
+		def()
+		return found
+	})
+	return value.(map[uint64][]interface{}), fresh
+}
+
 func getKeysForNils(engine *Engine, schema *tableSchema, rows map[string]interface{}, keysMapping map[string]uint64,
 	results map[string]Entity, fromRedis bool) []string {
 	keys := make([]string, 0)
@@ -162,9 +243,7 @@ func getKeysForNils(engine *Engine, schema *tableSchema, rows map[string]interfa
 				results[k] = nil
 			} else if fromRedis {
 				entity := reflect.New(schema.t).Interface().(Entity)
-				var decoded []interface{}
-				_ = jsoniter.ConfigFastest.Unmarshal([]byte(v.(string)), &decoded)
-				convertDataFromJSON(schema.fields, 0, decoded)
+				decoded := decodeRedisRow(engine.registry, schema, v.(string))
 				fillFromDBRow(keysMapping[k], engine, decoded, entity, false)
 				results[k] = entity
 			} else {
@@ -258,11 +337,16 @@ func warmUpReferences(engine *Engine, schema *tableSchema, rows reflect.Value, r
 				key = k
 				break
 			}
-			fromCache, has := engine.GetLocalCache(k).Get(key)
-			if has && fromCache != "nil" {
-				data := fromCache.([]interface{})
-				for _, r := range v[key] {
-					fillFromDBRow(data[0].(uint64), engine, data, r.Interface().(Entity), false)
+			localCache := engine.GetLocalCache(k)
+			fromCache, has := localCache.Get(key)
+			engine.registry.cacheMetrics.add(v[key][0].Interface().(Entity).getORM().tableSchema, "", localCacheLayer(localCache),
+				boolToInt(has), boolToInt(!has))
+			if has {
+				if fromCache != "nil" {
+					data := fromCache.([]interface{})
+					for _, r := range v[key] {
+						fillFromDBRow(data[0].(uint64), engine, data, r.Interface().(Entity), false)
+					}
 				}
 				fillRef(key, localMap, redisMap, dbMap)
 			}
@@ -273,17 +357,25 @@ func warmUpReferences(engine *Engine, schema *tableSchema, rows reflect.Value, r
 				keys[i] = k
 				i++
 			}
-			for key, fromCache := range engine.GetLocalCache(k).MGet(keys...) {
+			localCache := engine.GetLocalCache(k)
+			for key, fromCache := range localCache.MGet(keys...) {
+				engine.registry.cacheMetrics.add(v[key][0].Interface().(Entity).getORM().tableSchema, "", localCacheLayer(localCache),
+					boolToInt(fromCache != nil), boolToInt(fromCache == nil))
 				if fromCache != nil {
-					data := fromCache.([]interface{})
-					for _, r := range v[key] {
-						fillFromDBRow(data[0].(uint64), engine, data, r.Interface().(Entity), false)
+					if fromCache != "nil" {
+						data := fromCache.([]interface{})
+						for _, r := range v[key] {
+							fillFromDBRow(data[0].(uint64), engine, data, r.Interface().(Entity), false)
+						}
 					}
 					fillRef(key, localMap, redisMap, dbMap)
 				}
 			}
 		}
 	}
+	redisResults := make(map[string]map[string]interface{}, len(redisMap))
+	tasks := make([]func(), 0, len(redisMap))
+	var mutex sync.Mutex
 	for k, v := range redisMap {
 		l := len(v)
 		if l == 0 {
@@ -295,62 +387,104 @@ func warmUpReferences(engine *Engine, schema *tableSchema, rows reflect.Value, r
 			keys[i] = k
 			i++
 		}
-		for key, fromCache := range engine.GetRedis(k).MGet(keys...) {
+		pool := k
+		tasks = append(tasks, func() {
+			results := engine.GetRedis(pool).MGet(keys...)
+			mutex.Lock()
+			redisResults[pool] = results
+			mutex.Unlock()
+		})
+	}
+	runParallel(warmUpReferencesMaxGoroutines, tasks...)
+	for k, results := range redisResults {
+		v := redisMap[k]
+		for key, fromCache := range results {
+			schema := v[key][0].Interface().(Entity).getORM().tableSchema
+			engine.registry.cacheMetrics.add(schema, "", CacheLayerRedis, boolToInt(fromCache != nil), boolToInt(fromCache == nil))
 			if fromCache != nil {
-				schema := v[key][0].Interface().(Entity).getORM().tableSchema
-				decoded := make([]interface{}, len(schema.columnNames))
-				_ = jsoniter.ConfigFastest.Unmarshal([]byte(fromCache.(string)), &decoded)
-				convertDataFromJSON(schema.fields, 0, decoded)
-				for _, r := range v[key] {
-					fillFromDBRow(decoded[0].(uint64), engine, decoded, r.Interface().(Entity), false)
+				if fromCache != "nil" {
+					decoded := decodeRedisRow(engine.registry, schema, fromCache.(string))
+					for _, r := range v[key] {
+						fillFromDBRow(decoded[0].(uint64), engine, decoded, r.Interface().(Entity), false)
+					}
 				}
 				fillRef(key, nil, redisMap, dbMap)
 			}
 		}
 	}
+	dbResults := make(map[*tableSchema][][]interface{})
+	tasks = make([]func(), 0)
+	limit := warmUpReferencesMaxGoroutines
 	for k, v := range dbMap {
 		db := engine.GetMysql(k)
+		if db.inTransaction {
+			limit = 1
+		}
 		for schema, v2 := range v {
 			if len(v2) == 0 {
 				continue
 			}
-			keys := make([]string, len(v2))
 			q := make([]string, len(v2))
 			i := 0
 			for k2 := range v2 {
-				keys[i] = k2[strings.Index(k2, ":")+1:]
-				q[i] = keys[i]
+				q[i] = k2[strings.Index(k2, ":")+1:]
 				i++
 			}
-			query := "SELECT " + schema.fieldsQuery + " FROM `" + schema.tableName + "` WHERE `ID` IN (" + strings.Join(q, ",") + ")"
-			results, def := db.Query(query)
-			for results.Next() {
-				pointers := prepareScan(schema)
-				results.Scan(pointers...)
-				convertScan(schema.fields, 0, pointers)
+			schema := schema
+			tasks = append(tasks, func() {
+				query := "SELECT " + schema.fieldsQuery + " FROM `" + schema.tableName + "` WHERE `ID` IN (" + strings.Join(q, ",") + ")"
+				results, def := db.Query(query)
+				rows := make([][]interface{}, 0, len(q))
+				for results.Next() {
+					pointers := prepareScan(schema)
+					results.Scan(pointers...)
+					convertScan(schema.fields, 0, pointers)
+					rows = append(rows, pointers)
+				}
+				def()
+				mutex.Lock()
+				dbResults[schema] = rows
+				mutex.Unlock()
+			})
+		}
+	}
+	runParallel(limit, tasks...)
+	for _, v := range dbMap {
+		for schema, v2 := range v {
+			engine.registry.cacheMetrics.add(schema, "", CacheLayerDB, len(dbResults[schema]), len(v2)-len(dbResults[schema]))
+			for _, pointers := range dbResults[schema] {
 				id := pointers[0].(uint64)
 				for _, r := range v2[schema.getCacheKey(id)] {
 					fillFromDBRow(id, engine, pointers, r.Interface().(Entity), false)
 				}
 			}
-			def()
 		}
 	}
+	tasks = make([]func(), 0, len(redisMap))
 	for pool, v := range redisMap {
 		if len(v) == 0 {
 			continue
 		}
-		values := make([]interface{}, 0)
+		values := make(map[time.Duration][]interface{})
 		for cacheKey, refs := range v {
 			e := refs[0].Interface().(Entity)
+			ttl := e.getORM().tableSchema.redisSetTTL()
 			if e.Loaded() {
-				values = append(values, cacheKey, buildRedisValue(e))
+				values[ttl] = append(values[ttl], cacheKey, buildRedisValue(e))
 			} else {
-				values = append(values, cacheKey, "nil")
+				values[ttl] = append(values[ttl], cacheKey, "nil")
 			}
 		}
-		engine.GetRedis(pool).MSet(values...)
+		for ttl, pairs := range values {
+			pool := pool
+			ttl := ttl
+			pairs := pairs
+			tasks = append(tasks, func() {
+				engine.GetRedis(pool).mSetWithTTL(ttl, pairs...)
+			})
+		}
 	}
+	runParallel(warmUpReferencesMaxGoroutines, tasks...)
 	for pool, v := range localMap {
 		if len(v) == 0 {
 			continue
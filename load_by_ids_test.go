@@ -1,6 +1,7 @@
 package orm
 
 import (
+	"fmt"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -74,3 +75,76 @@ func TestLoadByIds(t *testing.T) {
 		engine.LoadByIDs([]uint64{1}, &rows)
 	})
 }
+
+type loadByIdsParallelEntity struct {
+	ORM            `orm:"localCache"`
+	ID             uint
+	Name           string
+	ReferenceLocal *loadByIdsReference
+	ReferenceRedis *loadByIdsRedisReference
+	ReferenceDB    *loadByIdsDBReference
+	ReferenceMany  []*loadByIdsDBReference
+}
+
+type loadByIdsRedisReference struct {
+	ORM  `orm:"redisCache=default_queue"`
+	ID   uint
+	Name string
+}
+
+type loadByIdsDBReference struct {
+	ORM
+	ID   uint
+	Name string
+}
+
+func TestLoadByIdsParallelReferences(t *testing.T) {
+	var entity *loadByIdsParallelEntity
+	var reference *loadByIdsReference
+	var subReference *loadByIdsSubReference
+	var redisReference *loadByIdsRedisReference
+	var dbReference *loadByIdsDBReference
+	engine := PrepareTables(t, &Registry{}, 5, entity, reference, subReference, redisReference, dbReference)
+
+	for i := 1; i <= 3; i++ {
+		name := fmt.Sprintf("n%d", i)
+		engine.Flush(&loadByIdsParallelEntity{Name: name,
+			ReferenceLocal: &loadByIdsReference{Name: "l" + name, ReferenceTwo: &loadByIdsSubReference{Name: "s" + name}},
+			ReferenceRedis: &loadByIdsRedisReference{Name: "r" + name},
+			ReferenceDB:    &loadByIdsDBReference{Name: "d" + name},
+		})
+	}
+	e := &loadByIdsParallelEntity{}
+	engine.LoadByID(1, e)
+	e.ReferenceMany = []*loadByIdsDBReference{{ID: 2}, {ID: 3}}
+	engine.Flush(e)
+
+	for i := 0; i < 2; i++ {
+		engine.GetLocalCache().Clear()
+		if i == 0 {
+			engine.GetRedis().FlushDB()
+			engine.GetRedis("default_queue").FlushDB()
+		}
+		var rows []*loadByIdsParallelEntity
+		missing := engine.LoadByIDs([]uint64{1, 2, 3}, &rows, "ReferenceLocal/ReferenceTwo", "ReferenceRedis",
+			"ReferenceDB", "ReferenceMany")
+		assert.Len(t, missing, 0)
+		assert.Len(t, rows, 3)
+		for j, row := range rows {
+			name := fmt.Sprintf("n%d", j+1)
+			assert.Equal(t, name, row.Name)
+			assert.True(t, row.ReferenceLocal.Loaded())
+			assert.Equal(t, "l"+name, row.ReferenceLocal.Name)
+			assert.True(t, row.ReferenceLocal.ReferenceTwo.Loaded())
+			assert.Equal(t, "s"+name, row.ReferenceLocal.ReferenceTwo.Name)
+			assert.True(t, row.ReferenceRedis.Loaded())
+			assert.Equal(t, "r"+name, row.ReferenceRedis.Name)
+			assert.True(t, row.ReferenceDB.Loaded())
+			assert.Equal(t, "d"+name, row.ReferenceDB.Name)
+		}
+		assert.Len(t, rows[0].ReferenceMany, 2)
+		assert.True(t, rows[0].ReferenceMany[0].Loaded())
+		assert.Equal(t, "dn2", rows[0].ReferenceMany[0].Name)
+		assert.Equal(t, "dn3", rows[0].ReferenceMany[1].Name)
+	}
+}
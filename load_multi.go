@@ -0,0 +1,292 @@
+package orm
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+type loadMultiTarget struct {
+	value          reflect.Value
+	schema         *tableSchema
+	ids            []uint64
+	lookupIDs      []uint64
+	rows           map[uint64][]interface{}
+	entities       map[uint64]Entity
+	localCacheCode string
+	redisCacheCode string
+	localMisses    []uint64
+	redisMisses    []uint64
+	dbMisses       []uint64
+}
+
+func loadMulti(engine *Engine, targets map[interface{}][]uint64) (missing map[interface{}][]uint64) {
+	missing = make(map[interface{}][]uint64)
+	all := make(map[interface{}]*loadMultiTarget, len(targets))
+	for pointer, ids := range targets {
+		value := reflect.ValueOf(pointer).Elem()
+		if len(ids) == 0 {
+			value.SetLen(0)
+			continue
+		}
+		t, has, name := getEntityTypeForSlice(engine.registry, value.Type())
+		if !has {
+			panic(fmt.Errorf("entity '%s' is not registered", name))
+		}
+		schema := getTableSchema(engine.registry, t)
+		localCache, hasLocalCache := schema.GetLocalCache(engine)
+		if !hasLocalCache && engine.dataLoader != nil {
+			missingIDs, _ := tryByIDs(engine, ids, true, value, nil)
+			if len(missingIDs) > 0 {
+				missing[pointer] = missingIDs
+			}
+			continue
+		}
+		target := &loadMultiTarget{value: value, schema: schema, ids: ids, rows: make(map[uint64][]interface{}, len(ids))}
+		target.lookupIDs = filterByBloomFilter(engine, schema, ids)
+		if !hasLocalCache && engine.hasRequestCache {
+			hasLocalCache = true
+			localCache = engine.GetLocalCache(requestCacheKey)
+		}
+		if hasLocalCache {
+			target.localCacheCode = localCache.code
+		}
+		redisCache, hasRedis := schema.GetRedisCache(engine)
+		if hasRedis {
+			target.redisCacheCode = redisCache.code
+		}
+		all[pointer] = target
+	}
+	if len(all) == 0 {
+		return missing
+	}
+	loadMultiFromLocalCache(engine, all)
+	loadMultiFromRedis(engine, all)
+	loadMultiFromDB(engine, all)
+	for _, target := range all {
+		target.entities = make(map[uint64]Entity, len(target.rows))
+		for id, row := range target.rows {
+			if row != nil {
+				entity := reflect.New(target.schema.t).Interface().(Entity)
+				fillFromDBRow(id, engine, row, entity, false)
+				target.entities[id] = entity
+			}
+		}
+	}
+	loadMultiFillCache(engine, all)
+
+	for pointer, target := range all {
+		v := target.value
+		v.SetLen(0)
+		v.SetCap(0)
+		for _, id := range target.ids {
+			entity, has := target.entities[id]
+			if !has {
+				missing[pointer] = append(missing[pointer], id)
+				continue
+			}
+			v = reflect.Append(v, reflect.ValueOf(entity))
+		}
+		target.value.Set(v)
+	}
+	return missing
+}
+
+func loadMultiFromLocalCache(engine *Engine, all map[interface{}]*loadMultiTarget) {
+	keys := make(map[string][]string)
+	for _, target := range all {
+		if target.localCacheCode == "" {
+			target.localMisses = target.lookupIDs
+			continue
+		}
+		for _, id := range target.lookupIDs {
+			keys[target.localCacheCode] = append(keys[target.localCacheCode], target.schema.getCacheKey(id))
+		}
+	}
+	if len(keys) == 0 {
+		return
+	}
+	results := make(map[string]map[string]interface{}, len(keys))
+	for code, cacheKeys := range keys {
+		results[code] = engine.GetLocalCache(code).MGet(cacheKeys...)
+	}
+	for _, target := range all {
+		if target.localCacheCode == "" {
+			continue
+		}
+		fromCache := results[target.localCacheCode]
+		for _, id := range target.lookupIDs {
+			value := fromCache[target.schema.getCacheKey(id)]
+			if value == nil {
+				target.localMisses = append(target.localMisses, id)
+			} else if value != "nil" {
+				target.rows[id] = value.([]interface{})
+			} else {
+				target.rows[id] = nil
+			}
+		}
+	}
+}
+
+func loadMultiFromRedis(engine *Engine, all map[interface{}]*loadMultiTarget) {
+	keys := make(map[string][]string)
+	for _, target := range all {
+		if target.redisCacheCode == "" {
+			target.redisMisses = target.localMisses
+			continue
+		}
+		for _, id := range target.localMisses {
+			keys[target.redisCacheCode] = append(keys[target.redisCacheCode], target.schema.getCacheKey(id))
+		}
+	}
+	if len(keys) == 0 {
+		return
+	}
+	results := make(map[string]map[string]interface{}, len(keys))
+	tasks := make([]func(), 0, len(keys))
+	var mutex sync.Mutex
+	for code, cacheKeys := range keys {
+		code := code
+		cacheKeys := cacheKeys
+		tasks = append(tasks, func() {
+			fromRedis := engine.GetRedis(code).MGet(cacheKeys...)
+			mutex.Lock()
+			results[code] = fromRedis
+			mutex.Unlock()
+		})
+	}
+	runParallel(len(tasks), tasks...)
+	for _, target := range all {
+		if target.redisCacheCode == "" {
+			continue
+		}
+		fromRedis := results[target.redisCacheCode]
+		for _, id := range target.localMisses {
+			value := fromRedis[target.schema.getCacheKey(id)]
+			if value == nil {
+				target.redisMisses = append(target.redisMisses, id)
+			} else if value != "nil" {
+				decoded := decodeRedisRow(engine.registry, target.schema, value.(string))
+				target.rows[id] = decoded
+			} else {
+				target.rows[id] = nil
+			}
+		}
+	}
+}
+
+func loadMultiFromDB(engine *Engine, all map[interface{}]*loadMultiTarget) {
+	tasks := make([]func(), 0, len(all))
+	limit := len(all)
+	for _, target := range all {
+		if len(target.redisMisses) == 0 {
+			continue
+		}
+		target := target
+		db := target.schema.GetMysql(engine)
+		if db.inTransaction {
+			limit = 1
+		}
+		tasks = append(tasks, func() {
+			q := make([]string, len(target.redisMisses))
+			for i, id := range target.redisMisses {
+				q[i] = strconv.FormatUint(id, 10)
+			}
+			schema := target.schema
+			/* #nosec */
+			query := "SELECT " + schema.fieldsQuery + " FROM `" + schema.tableName + "` WHERE `ID` IN (" + strings.Join(q, ",") + ")"
+			results, def := db.Query(query)
+			rows := make(map[uint64][]interface{}, len(target.redisMisses))
+			for results.Next() {
+				pointers := prepareScan(schema)
+				results.Scan(pointers...)
+				convertScan(schema.fields, 0, pointers)
+				rows[pointers[0].(uint64)] = pointers
+			}
+			def()
+			for _, id := range target.redisMisses {
+				target.rows[id] = rows[id]
+			}
+			target.dbMisses = target.redisMisses
+		})
+	}
+	runParallel(limit, tasks...)
+}
+
+func loadMultiFillCache(engine *Engine, all map[interface{}]*loadMultiTarget) {
+	localSets := make(map[string][]interface{})
+	redisSets := make(map[string]map[time.Duration][]interface{})
+	for _, target := range all {
+		if target.localCacheCode != "" {
+			for _, id := range target.localMisses {
+				var value interface{} = "nil"
+				entity, has := target.entities[id]
+				if has {
+					value = buildLocalCacheValue(entity)
+				}
+				localSets[target.localCacheCode] = append(localSets[target.localCacheCode], target.schema.getCacheKey(id), value)
+			}
+		}
+		if target.redisCacheCode != "" {
+			for _, id := range target.dbMisses {
+				var value interface{} = "nil"
+				entity, has := target.entities[id]
+				if has {
+					value = buildRedisValue(entity)
+				}
+				if redisSets[target.redisCacheCode] == nil {
+					redisSets[target.redisCacheCode] = make(map[time.Duration][]interface{})
+				}
+				ttl := target.schema.redisSetTTL()
+				redisSets[target.redisCacheCode][ttl] = append(redisSets[target.redisCacheCode][ttl], target.schema.getCacheKey(id), value)
+			}
+		}
+	}
+	for code, pairs := range localSets {
+		engine.GetLocalCache(code).MSet(pairs...)
+	}
+	for code, values := range redisSets {
+		for ttl, pairs := range values {
+			engine.GetRedis(code).mSetWithTTL(ttl, pairs...)
+		}
+	}
+}
+
+func runParallel(limit int, tasks ...func()) {
+	if len(tasks) == 0 {
+		return
+	}
+	if limit <= 1 || len(tasks) == 1 {
+		for _, task := range tasks {
+			task()
+		}
+		return
+	}
+	var wg sync.WaitGroup
+	var mutex sync.Mutex
+	var recovered interface{}
+	semaphore := make(chan struct{}, limit)
+	for _, task := range tasks {
+		wg.Add(1)
+		semaphore <- struct{}{}
+		go func(task func()) {
+			defer func() {
+				if r := recover(); r != nil {
+					mutex.Lock()
+					recovered = r
+					mutex.Unlock()
+				}
+				<-semaphore
+				wg.Done()
+			}()
+			task()
+		}(task)
+	}
+	wg.Wait()
+	if recovered != nil {
+		panic(recovered)
+	}
+}
@@ -0,0 +1,86 @@
+package orm
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type loadMultiEntityLocal struct {
+	ORM  `orm:"localCache;redisCache"`
+	ID   uint
+	Name string
+}
+
+type loadMultiEntityRedis struct {
+	ORM  `orm:"redisCache"`
+	ID   uint
+	Name string
+}
+
+type loadMultiEntityNoCache struct {
+	ORM
+	ID   uint
+	Name string
+}
+
+func TestLoadMulti(t *testing.T) {
+	var entityLocal *loadMultiEntityLocal
+	var entityRedis *loadMultiEntityRedis
+	var entityNoCache *loadMultiEntityNoCache
+	engine := PrepareTables(t, &Registry{}, 5, entityLocal, entityRedis, entityNoCache)
+
+	flusher := engine.NewFlusher()
+	for _, name := range []string{"a", "b", "c"} {
+		flusher.Track(&loadMultiEntityLocal{Name: name}, &loadMultiEntityRedis{Name: name}, &loadMultiEntityNoCache{Name: name})
+	}
+	flusher.Flush()
+	engine.GetLocalCache().Clear()
+	engine.GetRedis().FlushDB()
+
+	var rowsLocal []*loadMultiEntityLocal
+	var rowsRedis []*loadMultiEntityRedis
+	var rowsNoCache []*loadMultiEntityNoCache
+	missing := engine.LoadMulti(map[interface{}][]uint64{&rowsLocal: {3, 1, 4}, &rowsRedis: {2, 3}, &rowsNoCache: {1, 5, 6}})
+	assert.Len(t, missing, 2)
+	assert.Equal(t, []uint64{4}, missing[&rowsLocal])
+	assert.Equal(t, []uint64{5, 6}, missing[&rowsNoCache])
+	assert.Len(t, rowsLocal, 2)
+	assert.Equal(t, "c", rowsLocal[0].Name)
+	assert.Equal(t, "a", rowsLocal[1].Name)
+	assert.True(t, rowsLocal[0].Loaded())
+	assert.Len(t, rowsRedis, 2)
+	assert.Equal(t, uint(2), rowsRedis[0].ID)
+	assert.Equal(t, "b", rowsRedis[0].Name)
+	assert.Len(t, rowsNoCache, 1)
+	assert.Equal(t, "a", rowsNoCache[0].Name)
+
+	engine.GetMysql().Exec("DELETE FROM `loadMultiEntityLocal`")
+	engine.GetMysql().Exec("DELETE FROM `loadMultiEntityRedis`")
+	engine.GetMysql().Exec("DELETE FROM `loadMultiEntityNoCache`")
+	missing = engine.LoadMulti(map[interface{}][]uint64{&rowsLocal: {3, 1, 4}, &rowsRedis: {2, 3}, &rowsNoCache: {1}})
+	assert.Len(t, missing, 2)
+	assert.Equal(t, []uint64{4}, missing[&rowsLocal])
+	assert.Equal(t, []uint64{1}, missing[&rowsNoCache])
+	assert.Len(t, rowsLocal, 2)
+	assert.Len(t, rowsRedis, 2)
+	assert.Equal(t, "c", rowsRedis[1].Name)
+	assert.Len(t, rowsNoCache, 0)
+
+	engine.GetLocalCache().Clear()
+	missing = engine.LoadMulti(map[interface{}][]uint64{&rowsLocal: {1}, &rowsRedis: {}})
+	assert.Len(t, missing, 0)
+	assert.Len(t, rowsLocal, 1)
+	assert.Equal(t, "a", rowsLocal[0].Name)
+	assert.Len(t, rowsRedis, 0)
+
+	assert.PanicsWithError(t, "entity 'orm.loadMultiEntityUnknown' is not registered", func() {
+		var rows []*loadMultiEntityUnknown
+		engine.LoadMulti(map[interface{}][]uint64{&rows: {1}})
+	})
+}
+
+type loadMultiEntityUnknown struct {
+	ORM
+	ID uint
+}
@@ -5,50 +5,65 @@ import (
 	"time"
 
 	log2 "github.com/apex/log"
-
-	"github.com/golang/groupcache/lru"
 )
 
 const requestCacheKey = "_request"
 
 type LocalCacheConfig struct {
-	code string
-	lru  *lru.Cache
-	m    sync.Mutex
+	code   string
+	shards []*localCacheShard
+	ttl    map[string]time.Duration
+	m      sync.Mutex
 }
 
 type LocalCache struct {
 	engine *Engine
 	code   string
-	lru    *lru.Cache
-	m      *sync.Mutex
+	config *LocalCacheConfig
+}
+
+func (c *LocalCacheConfig) setTTL(cachePrefix string, ttl time.Duration) {
+	c.m.Lock()
+	defer c.m.Unlock()
+	if c.ttl == nil {
+		c.ttl = make(map[string]time.Duration)
+	}
+	c.ttl[cachePrefix] = ttl
 }
 
 type ttlValue struct {
 	value interface{}
 	time  int64
+	delta time.Duration
 }
 
 func (c *LocalCache) GetSet(key string, ttlSeconds int, provider GetSetProvider) interface{} {
 	val, has := c.Get(key)
 	if has {
 		ttlVal := val.(ttlValue)
-		if time.Now().Unix()-ttlVal.time <= int64(ttlSeconds) {
+		remaining := time.Duration(ttlSeconds)*time.Second - time.Duration(time.Now().UnixNano()-ttlVal.time)
+		if !refreshEarly(ttlVal.delta, remaining) {
 			return ttlVal.value
 		}
 	}
-	userVal := provider()
-	val = ttlValue{value: userVal, time: time.Now().Unix()}
-	c.Set(key, val)
+	userVal, fresh := c.engine.registry.stampedeProtection.do(c.engine, "local:"+c.code+":"+key, nil, func() interface{} {
+		start := time.Now()
+		userVal := provider()
+		c.Set(key, ttlValue{value: userVal, time: time.Now().UnixNano(), delta: time.Since(start)})
+		return userVal
+	})
+	if !fresh && c.code == requestCacheKey {
+		c.Set(key, ttlValue{value: userVal, time: time.Now().UnixNano()})
+	}
 	return userVal
 }
 
 func (c *LocalCache) Get(key string) (value interface{}, ok bool) {
-	c.m.Lock()
-	defer c.m.Unlock()
-
 	start := time.Now()
-	value, ok = c.lru.Get(key)
+	shard := c.config.shard(key)
+	shard.m.Lock()
+	value, ok = shard.get(key, start)
+	shard.m.Unlock()
 	misses := 0
 	if !ok {
 		misses = 1
@@ -60,19 +75,20 @@ func (c *LocalCache) Get(key string) (value interface{}, ok bool) {
 }
 
 func (c *LocalCache) MGet(keys ...string) map[string]interface{} {
-	c.m.Lock()
-	defer c.m.Unlock()
-
 	start := time.Now()
 	results := make(map[string]interface{}, len(keys))
 	misses := 0
-	for _, key := range keys {
-		value, ok := c.lru.Get(key)
-		if !ok {
-			misses++
-			value = nil
+	for shard, shardKeys := range c.groupByShard(keys) {
+		shard.m.Lock()
+		for _, key := range shardKeys {
+			value, ok := shard.get(key, start)
+			if !ok {
+				misses++
+				value = nil
+			}
+			results[key] = value
 		}
-		results[key] = value
+		shard.m.Unlock()
 	}
 	if c.engine.hasLocalCacheLogger {
 		c.fillLogFields("[ORM][LOCAL][MGET]", start, "mget", misses, map[string]interface{}{"Keys": keys})
@@ -82,9 +98,10 @@ func (c *LocalCache) MGet(keys ...string) map[string]interface{} {
 
 func (c *LocalCache) Set(key string, value interface{}) {
 	start := time.Now()
-	c.m.Lock()
-	defer c.m.Unlock()
-	c.lru.Add(key, value)
+	shard := c.config.shard(key)
+	shard.m.Lock()
+	shard.add(key, value, start)
+	shard.m.Unlock()
 	if c.engine.hasLocalCacheLogger {
 		c.fillLogFields("[ORM][LOCAL][MGET]", start, "set", -1, map[string]interface{}{"Key": key, "value": value})
 	}
@@ -93,10 +110,11 @@ func (c *LocalCache) Set(key string, value interface{}) {
 func (c *LocalCache) MSet(pairs ...interface{}) {
 	start := time.Now()
 	max := len(pairs)
-	c.m.Lock()
-	defer c.m.Unlock()
 	for i := 0; i < max; i += 2 {
-		c.lru.Add(pairs[i], pairs[i+1])
+		shard := c.config.shard(pairs[i].(string))
+		shard.m.Lock()
+		shard.add(pairs[i], pairs[i+1], start)
+		shard.m.Unlock()
 	}
 	if c.engine.hasLocalCacheLogger {
 		c.fillLogFields("[ORM][LOCAL][MSET]", start, "mset", -1, map[string]interface{}{"Keys": pairs})
@@ -104,13 +122,14 @@ func (c *LocalCache) MSet(pairs ...interface{}) {
 }
 
 func (c *LocalCache) HMget(key string, fields ...string) map[string]interface{} {
-	c.m.Lock()
-	defer c.m.Unlock()
-
 	start := time.Now()
+	shard := c.config.shard(key)
+	shard.m.Lock()
+	defer shard.m.Unlock()
+
 	l := len(fields)
 	results := make(map[string]interface{}, l)
-	value, ok := c.lru.Get(key)
+	value, ok := shard.get(key, start)
 	misses := 0
 	for _, field := range fields {
 		if !ok {
@@ -133,18 +152,20 @@ func (c *LocalCache) HMget(key string, fields ...string) map[string]interface{}
 }
 
 func (c *LocalCache) HMset(key string, fields map[string]interface{}) {
-	c.m.Lock()
-	defer c.m.Unlock()
-
 	start := time.Now()
-	m, has := c.lru.Get(key)
+	shard := c.config.shard(key)
+	shard.m.Lock()
+	defer shard.m.Unlock()
+
+	m, has := shard.get(key, start)
 	if !has {
 		m = make(map[string]interface{})
-		c.lru.Add(key, m)
+		shard.add(key, m, start)
 	}
 	for k, v := range fields {
 		m.(map[string]interface{})[k] = v
 	}
+	shard.resize(key, m)
 	if c.engine.hasLocalCacheLogger {
 		c.fillLogFields("[ORM][LOCAL][HMSET]", start, "hmset", -1, map[string]interface{}{"Key": key, "fields": fields})
 	}
@@ -152,10 +173,12 @@ func (c *LocalCache) HMset(key string, fields map[string]interface{}) {
 
 func (c *LocalCache) Remove(keys ...string) {
 	start := time.Now()
-	c.m.Lock()
-	defer c.m.Unlock()
-	for _, v := range keys {
-		c.lru.Remove(v)
+	for shard, shardKeys := range c.groupByShard(keys) {
+		shard.m.Lock()
+		for _, key := range shardKeys {
+			shard.remove(key)
+		}
+		shard.m.Unlock()
 	}
 	if c.engine.hasLocalCacheLogger {
 		c.fillLogFields("[ORM][LOCAL][REMOVE]", start, "remove", -1, map[string]interface{}{"Keys": keys})
@@ -163,22 +186,44 @@ func (c *LocalCache) Remove(keys ...string) {
 }
 
 func (c *LocalCache) GetObjectsCount() int {
-	c.m.Lock()
-	defer c.m.Unlock()
+	total := 0
+	for _, shard := range c.config.shards {
+		shard.m.Lock()
+		total += shard.lru.Len()
+		shard.m.Unlock()
+	}
+	return total
+}
 
-	return c.lru.Len()
+func (c *LocalCache) GetShardsStats() []LocalCacheShardStats {
+	stats := make([]LocalCacheShardStats, len(c.config.shards))
+	for i, shard := range c.config.shards {
+		stats[i] = shard.stats()
+	}
+	return stats
 }
 
 func (c *LocalCache) Clear() {
 	start := time.Now()
-	c.m.Lock()
-	defer c.m.Unlock()
-	c.lru.Clear()
+	for _, shard := range c.config.shards {
+		shard.m.Lock()
+		shard.clear()
+		shard.m.Unlock()
+	}
 	if c.engine.hasLocalCacheLogger {
 		c.fillLogFields("[ORM][LOCAL][CLEAR]", start, "clear", -1, nil)
 	}
 }
 
+func (c *LocalCache) groupByShard(keys []string) map[*localCacheShard][]string {
+	groups := make(map[*localCacheShard][]string)
+	for _, key := range keys {
+		shard := c.config.shard(key)
+		groups[shard] = append(groups[shard], key)
+	}
+	return groups
+}
+
 func (c *LocalCache) fillLogFields(message string, start time.Time, operation string, misses int, fields log2.Fields) {
 	stop := time.Since(start).Microseconds()
 	e := c.engine.queryLoggers[QueryLoggerSourceLocalCache].log.WithFields(log2.Fields{
@@ -0,0 +1,185 @@
+package orm
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	jsoniter "github.com/json-iterator/go"
+)
+
+const localCacheInvalidationChannelPrefix = "orm-local-cache-invalidation:"
+
+type localCacheInvalidationMessage struct {
+	Instance string   `json:"i"`
+	Sequence uint64   `json:"s"`
+	Keys     []string `json:"k"`
+}
+
+type localCacheInvalidator struct {
+	registry       *validatedRegistry
+	redisPool      string
+	instance       string
+	sequences      map[string]*uint64
+	publishLocks   map[string]*sync.Mutex
+	lastSequences  map[string]map[string]uint64
+	reconnectDelay time.Duration
+	channelPrefix  string
+	once           sync.Once
+	cancel         context.CancelFunc
+	done           chan struct{}
+}
+
+func newLocalCacheInvalidator(registry *validatedRegistry, redisPool string) *localCacheInvalidator {
+	invalidator := &localCacheInvalidator{registry: registry, redisPool: redisPool, reconnectDelay: time.Second}
+	invalidator.instance = fmt.Sprintf("%d-%d", os.Getpid(), time.Now().UnixNano())
+	invalidator.sequences = make(map[string]*uint64, len(registry.localCacheContainers))
+	invalidator.publishLocks = make(map[string]*sync.Mutex, len(registry.localCacheContainers))
+	invalidator.lastSequences = make(map[string]map[string]uint64, len(registry.localCacheContainers))
+	for code := range registry.localCacheContainers {
+		invalidator.sequences[code] = new(uint64)
+		invalidator.publishLocks[code] = &sync.Mutex{}
+		invalidator.lastSequences[code] = make(map[string]uint64)
+	}
+	return invalidator
+}
+
+func (i *localCacheInvalidator) start() {
+	i.once.Do(func() {
+		if len(i.sequences) == 0 {
+			return
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		i.cancel = cancel
+		i.done = make(chan struct{})
+		engine := &Engine{registry: i.registry, context: ctx}
+		redisCache := engine.GetRedis(i.redisPool)
+		i.channelPrefix = redisCache.prefixKey(localCacheInvalidationChannelPrefix)
+		channels := make([]string, 0, len(i.sequences))
+		for code := range i.sequences {
+			channels = append(channels, i.channelPrefix+code)
+		}
+		pubSub := redisCache.client.Subscribe(ctx, channels...)
+		go i.run(ctx, engine, pubSub)
+	})
+}
+
+func (i *localCacheInvalidator) stop() {
+	if i.cancel != nil {
+		i.cancel()
+		<-i.done
+	}
+}
+
+func (i *localCacheInvalidator) run(ctx context.Context, engine *Engine, pubSub *redis.PubSub) {
+	defer func() {
+		_ = pubSub.Close()
+		close(i.done)
+	}()
+	subscribed := make(map[string]bool)
+	lost := false
+	for {
+		message, err := pubSub.Receive(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			lost = true
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(i.reconnectDelay):
+			}
+			continue
+		}
+		if lost {
+			i.clearAll(engine)
+			lost = false
+		}
+		switch m := message.(type) {
+		case *redis.Subscription:
+			if m.Kind == "subscribe" {
+				if subscribed[m.Channel] {
+					i.clear(engine, m.Channel[len(i.channelPrefix):])
+				}
+				subscribed[m.Channel] = true
+			}
+		case *redis.Message:
+			i.handle(engine, m.Channel[len(i.channelPrefix):], m.Payload)
+		}
+	}
+}
+
+func (i *localCacheInvalidator) handle(engine *Engine, code string, payload string) {
+	lastSequences, has := i.lastSequences[code]
+	if !has {
+		return
+	}
+	message := &localCacheInvalidationMessage{}
+	err := jsoniter.ConfigFastest.Unmarshal([]byte(payload), message)
+	if err != nil {
+		i.clear(engine, code)
+		return
+	}
+	if message.Instance == i.instance {
+		return
+	}
+	last, has := lastSequences[message.Instance]
+	lastSequences[message.Instance] = message.Sequence
+	if has && message.Sequence != last+1 {
+		i.clear(engine, code)
+		return
+	}
+	if len(message.Keys) > 0 {
+		engine.GetLocalCache(code).Remove(message.Keys...)
+	}
+}
+
+func (i *localCacheInvalidator) clear(engine *Engine, code string) {
+	_, has := i.registry.localCacheContainers[code]
+	if has {
+		engine.GetLocalCache(code).Clear()
+	}
+}
+
+func (i *localCacheInvalidator) clearAll(engine *Engine) {
+	for code := range i.registry.localCacheContainers {
+		engine.GetLocalCache(code).Clear()
+	}
+}
+
+func (i *localCacheInvalidator) publish(engine *Engine, code string, keys []string) {
+	sequence, has := i.sequences[code]
+	if !has {
+		return
+	}
+	lock := i.publishLocks[code]
+	lock.Lock()
+	defer lock.Unlock()
+	*sequence++
+	message := &localCacheInvalidationMessage{Instance: i.instance, Sequence: *sequence, Keys: keys}
+	encoded, _ := jsoniter.ConfigFastest.Marshal(message)
+	engine.GetRedis(i.redisPool).Publish(localCacheInvalidationChannelPrefix+code, string(encoded))
+}
+
+func publishLocalCacheInvalidation(engine *Engine, code string, keys ...string) {
+	invalidator := engine.registry.localCacheInvalidator
+	if invalidator == nil || code == requestCacheKey || len(keys) == 0 {
+		return
+	}
+	invalidator.publish(engine, code, keys)
+}
+
+func publishLocalCacheSetsInvalidation(engine *Engine, code string, pairs []interface{}) {
+	if engine.registry.localCacheInvalidator == nil || code == requestCacheKey {
+		return
+	}
+	keys := make([]string, 0, len(pairs)/2)
+	for i := 0; i < len(pairs); i += 2 {
+		keys = append(keys, pairs[i].(string))
+	}
+	publishLocalCacheInvalidation(engine, code, keys...)
+}
@@ -0,0 +1,85 @@
+package orm
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type localCacheInvalidationEntity struct {
+	ORM  `orm:"localCache"`
+	ID   uint
+	Name string
+}
+
+func TestLocalCacheInvalidation(t *testing.T) {
+	var entity *localCacheInvalidationEntity
+	registryA := &Registry{}
+	registryA.RegisterLocalCacheInvalidation()
+	engineA := PrepareTables(t, registryA, 5, entity)
+	registryB := &Registry{}
+	registryB.RegisterLocalCacheInvalidation()
+	engineB := PrepareTables(t, registryB, 5, entity)
+	invalidatorB := engineB.registry.localCacheInvalidator
+	defer engineA.GetRegistry().Close()
+	defer engineB.GetRegistry().Close()
+
+	channel := localCacheInvalidationChannelPrefix + "default"
+	waitFor(t, func() bool {
+		return engineA.GetRedis().client.PubSubNumSub(context.Background(), channel).Val()[channel] >= 2
+	})
+
+	entityB := &localCacheInvalidationEntity{Name: "John"}
+	engineB.Flush(entityB)
+	cacheKey := entityB.getORM().tableSchema.getCacheKey(1)
+	_, has := engineB.GetLocalCache().Get(cacheKey)
+	assert.True(t, has)
+
+	entityA := &localCacheInvalidationEntity{}
+	assert.True(t, engineA.LoadByID(1, entityA))
+	entityA.Name = "Tom"
+	engineA.Flush(entityA)
+	waitFor(t, func() bool {
+		_, has := engineB.GetLocalCache().Get(cacheKey)
+		return !has
+	})
+	entityB = &localCacheInvalidationEntity{}
+	assert.True(t, engineB.LoadByID(1, entityB))
+	assert.Equal(t, "Tom", entityB.Name)
+
+	engineA.ClearByIDs(entityA, 1)
+	waitFor(t, func() bool {
+		_, has := engineB.GetLocalCache().Get(cacheKey)
+		return !has
+	})
+
+	engineB.GetRegistry().Close()
+	engineB.GetLocalCache().Set("a", "a")
+	engineB.GetLocalCache().Set("b", "b")
+	invalidatorB.handle(engineB, "default", `{"i":"test","s":1,"k":["a"]}`)
+	assert.Equal(t, 1, engineB.GetLocalCache().GetObjectsCount())
+	invalidatorB.handle(engineB, "default", `{"i":"test","s":3,"k":[]}`)
+	assert.Equal(t, 0, engineB.GetLocalCache().GetObjectsCount())
+	engineB.GetLocalCache().Set("a", "a")
+	invalidatorB.handle(engineB, "default", `{"i":"`+invalidatorB.instance+`","s":1,"k":["a"]}`)
+	assert.Equal(t, 1, engineB.GetLocalCache().GetObjectsCount())
+	invalidatorB.handle(engineB, "default", "invalid")
+	assert.Equal(t, 0, engineB.GetLocalCache().GetObjectsCount())
+
+	registry := &Registry{}
+	registry.RegisterLocalCacheInvalidation("invalid")
+	_, err := registry.Validate()
+	assert.EqualError(t, err, "local cache invalidation redis pool 'invalid' not found")
+}
+
+func waitFor(t *testing.T, condition func() bool) {
+	for i := 0; i < 100; i++ {
+		if condition() {
+			return
+		}
+		time.Sleep(time.Millisecond * 20)
+	}
+	assert.Fail(t, "condition not met")
+}
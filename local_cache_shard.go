@@ -0,0 +1,184 @@
+package orm
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang/groupcache/lru"
+	"github.com/segmentio/fasthash/fnv1a"
+)
+
+const localCacheEntryOverhead = 64
+
+type LocalCacheOptions struct {
+	Size     int
+	Shards   int
+	MaxBytes int64
+}
+
+type LocalCacheShardStats struct {
+	Hits      uint64
+	Misses    uint64
+	Evictions uint64
+	Objects   int
+	Bytes     int64
+}
+
+type localCacheShard struct {
+	m         sync.Mutex
+	lru       *lru.Cache
+	config    *LocalCacheConfig
+	expires   map[interface{}]int64
+	sizes     map[interface{}]int64
+	bytes     int64
+	maxBytes  int64
+	removing  bool
+	hits      uint64
+	misses    uint64
+	evictions uint64
+}
+
+func newLocalCacheConfig(code string, options LocalCacheOptions) *LocalCacheConfig {
+	if options.Shards <= 0 {
+		options.Shards = 1
+	}
+	config := &LocalCacheConfig{code: code, shards: make([]*localCacheShard, options.Shards)}
+	size := 0
+	if options.Size > 0 {
+		size = (options.Size + options.Shards - 1) / options.Shards
+	}
+	maxBytes := options.MaxBytes / int64(options.Shards)
+	for i := 0; i < options.Shards; i++ {
+		shard := &localCacheShard{config: config, lru: lru.New(size), maxBytes: maxBytes, expires: make(map[interface{}]int64)}
+		if maxBytes > 0 {
+			shard.sizes = make(map[interface{}]int64)
+		}
+		shard.lru.OnEvicted = shard.onEvicted
+		config.shards[i] = shard
+	}
+	return config
+}
+
+func (c *LocalCacheConfig) shard(key string) *localCacheShard {
+	if len(c.shards) == 1 {
+		return c.shards[0]
+	}
+	return c.shards[fnv1a.HashString32(key)%uint32(len(c.shards))]
+}
+
+func (c *LocalCacheConfig) keyTTL(key interface{}) (ttl time.Duration, has bool) {
+	if c.ttl == nil {
+		return 0, false
+	}
+	asString, is := key.(string)
+	if !is {
+		return 0, false
+	}
+	separator := strings.IndexAny(asString, ":_")
+	if separator <= 0 {
+		return 0, false
+	}
+	ttl, has = c.ttl[asString[0:separator]]
+	return ttl, has
+}
+
+func (s *localCacheShard) get(key string, now time.Time) (value interface{}, ok bool) {
+	value, ok = s.lru.Get(key)
+	if ok {
+		deadline, has := s.expires[key]
+		if has && now.UnixNano() >= deadline {
+			s.remove(key)
+			ok = false
+			value = nil
+		}
+	}
+	if ok {
+		s.hits++
+	} else {
+		s.misses++
+	}
+	return value, ok
+}
+
+func (s *localCacheShard) add(key interface{}, value interface{}, now time.Time) {
+	s.lru.Add(key, value)
+	ttl, has := s.config.keyTTL(key)
+	if has {
+		s.expires[key] = now.Add(ttl).UnixNano()
+	} else {
+		delete(s.expires, key)
+	}
+	s.resize(key, value)
+}
+
+func (s *localCacheShard) resize(key interface{}, value interface{}) {
+	if s.sizes == nil {
+		return
+	}
+	size := estimateLocalCacheSize(key) + estimateLocalCacheSize(value) + localCacheEntryOverhead
+	s.bytes += size - s.sizes[key]
+	s.sizes[key] = size
+	for s.bytes > s.maxBytes && s.lru.Len() > 0 {
+		s.lru.RemoveOldest()
+	}
+}
+
+func (s *localCacheShard) remove(key string) {
+	s.removing = true
+	s.lru.Remove(key)
+	s.removing = false
+}
+
+func (s *localCacheShard) clear() {
+	s.removing = true
+	s.lru.Clear()
+	s.removing = false
+	s.bytes = 0
+}
+
+func (s *localCacheShard) onEvicted(key lru.Key, _ interface{}) {
+	delete(s.expires, key)
+	if s.sizes != nil {
+		s.bytes -= s.sizes[key]
+		delete(s.sizes, key)
+	}
+	if !s.removing {
+		s.evictions++
+	}
+}
+
+func (s *localCacheShard) stats() LocalCacheShardStats {
+	s.m.Lock()
+	defer s.m.Unlock()
+	return LocalCacheShardStats{Hits: s.hits, Misses: s.misses, Evictions: s.evictions, Objects: s.lru.Len(), Bytes: s.bytes}
+}
+
+func estimateLocalCacheSize(value interface{}) int64 {
+	switch v := value.(type) {
+	case nil:
+		return 0
+	case string:
+		return int64(len(v)) + 16
+	case []byte:
+		return int64(len(v)) + 24
+	case []uint64:
+		return int64(len(v))*8 + 24
+	case []interface{}:
+		size := int64(24)
+		for _, row := range v {
+			size += estimateLocalCacheSize(row) + 16
+		}
+		return size
+	case map[string]interface{}:
+		size := int64(48)
+		for k, row := range v {
+			size += estimateLocalCacheSize(k) + estimateLocalCacheSize(row) + 16
+		}
+		return size
+	case ttlValue:
+		return estimateLocalCacheSize(v.value) + 24
+	}
+	return int64(reflect.TypeOf(value).Size())
+}
@@ -1,6 +1,8 @@
 package orm
 
 import (
+	"fmt"
+	"strings"
 	"testing"
 
 	apexLog "github.com/apex/log"
@@ -76,3 +78,65 @@ func TestLocalCache(t *testing.T) {
 	assert.Nil(t, valuesMap["a"])
 	assert.Nil(t, valuesMap["b"])
 }
+
+func TestLocalCacheSharded(t *testing.T) {
+	registry := &Registry{}
+	registry.RegisterLocalCacheWithOptions(LocalCacheOptions{Size: 100, Shards: 4})
+	registry.RegisterLocalCacheWithOptions(LocalCacheOptions{Shards: 2, MaxBytes: 2000}, "bytes")
+	assert.PanicsWithError(t, "local cache invalid requires size or max bytes", func() {
+		registry.RegisterLocalCacheWithOptions(LocalCacheOptions{Shards: 2}, "invalid")
+	})
+	assert.PanicsWithError(t, "local cache invalid options can't be negative", func() {
+		registry.RegisterLocalCacheWithOptions(LocalCacheOptions{Size: 100, MaxBytes: -1}, "invalid")
+	})
+	assert.PanicsWithError(t, "local cache invalid requires size or max bytes", func() {
+		registry.RegisterLocalCache(0, "invalid")
+	})
+	validatedRegistry, err := registry.Validate()
+	assert.Nil(t, err)
+	engine := validatedRegistry.CreateEngine()
+
+	c := engine.GetLocalCache()
+	for i := 0; i < 50; i++ {
+		c.Set(fmt.Sprintf("key_%d", i), i)
+	}
+	assert.Equal(t, 50, c.GetObjectsCount())
+	values := c.MGet("key_1", "key_2", "key_3", "missing")
+	assert.Equal(t, 1, values["key_1"])
+	assert.Equal(t, 3, values["key_3"])
+	assert.Nil(t, values["missing"])
+	c.Remove("key_1", "key_2")
+	assert.Equal(t, 48, c.GetObjectsCount())
+
+	stats := c.GetShardsStats()
+	assert.Len(t, stats, 4)
+	var hits, misses, evictions uint64
+	objects := 0
+	for _, shard := range stats {
+		hits += shard.Hits
+		misses += shard.Misses
+		evictions += shard.Evictions
+		objects += shard.Objects
+	}
+	assert.Equal(t, uint64(3), hits)
+	assert.Equal(t, uint64(1), misses)
+	assert.Equal(t, uint64(0), evictions)
+	assert.Equal(t, 48, objects)
+	c.Clear()
+	assert.Equal(t, 0, c.GetObjectsCount())
+
+	c = engine.GetLocalCache("bytes")
+	value := strings.Repeat("a", 200)
+	for i := 0; i < 20; i++ {
+		c.Set(fmt.Sprintf("key_%d", i), value)
+	}
+	evictions = 0
+	for _, shard := range c.GetShardsStats() {
+		assert.LessOrEqual(t, shard.Bytes, int64(1000))
+		evictions += shard.Evictions
+	}
+	assert.Greater(t, evictions, uint64(0))
+	assert.Less(t, c.GetObjectsCount(), 20)
+	c.HMset("hash", map[string]interface{}{"a": strings.Repeat("b", 300)})
+	assert.Equal(t, strings.Repeat("b", 300), c.HMget("hash", "a")["a"])
+}
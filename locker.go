@@ -25,6 +25,7 @@ func (l *standardLockerClient) Obtain(ctx context.Context, key string, ttl time.
 
 type Locker struct {
 	code   string
+	prefix string
 	locker lockerClient
 	engine *Engine
 }
@@ -44,7 +45,7 @@ func (l *Locker) Obtain(ctx context.Context, key string, ttl time.Duration, wait
 		options = &redislock.Options{RetryStrategy: redislock.LimitRetry(redislock.ExponentialBackoff(minInterval, maxInterval), max)}
 	}
 	start := time.Now()
-	redisLock, err := l.locker.Obtain(ctx, key, ttl, options)
+	redisLock, err := l.locker.Obtain(ctx, l.prefix+key, ttl, options)
 	if err != nil {
 		if err == redislock.ErrNotObtained {
 			if l.engine.hasRedisLogger {
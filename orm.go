@@ -27,6 +27,8 @@ type ORM struct {
 	dBData               []interface{}
 	tableSchema          *tableSchema
 	onDuplicateKeyUpdate map[string]interface{}
+	upsertIndex          string
+	upsertFields         []string
 	initialised          bool
 	loaded               bool
 	inDB                 bool
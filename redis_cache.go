@@ -2,6 +2,8 @@ package orm
 
 import (
 	"context"
+	"crypto/sha1"
+	"fmt"
 	"strings"
 	"time"
 
@@ -17,7 +19,9 @@ type RedisCache struct {
 	engine  *Engine
 	ctx     context.Context
 	code    string
-	client  *redis.Client
+	client  redis.UniversalClient
+	cluster bool
+	prefix  string
 	limiter *redis_rate.Limiter
 }
 
@@ -28,7 +32,7 @@ func (r *RedisCache) RateLimit(key string, limit redis_rate.Limit) bool {
 		r.limiter = redis_rate.NewLimiter(r.client)
 	}
 	start := time.Now()
-	res, err := r.limiter.Allow(r.client.Context(), key, limit)
+	res, err := r.limiter.Allow(r.client.Context(), r.prefixKey(key), limit)
 	if r.engine.hasRedisLogger {
 		r.fillLogFields("[ORM][REDIS][RATE_LIMIT]", start,
 			"rate_limit", 0, 1, map[string]interface{}{"Key": key}, err)
@@ -38,20 +42,48 @@ func (r *RedisCache) RateLimit(key string, limit redis_rate.Limit) bool {
 }
 
 func (r *RedisCache) GetSet(key string, ttlSeconds int, provider GetSetProvider) interface{} {
-	val, has := r.Get(key)
-	if !has {
+	stampede := r.engine.registry.stampedeProtection
+	stampedeKey := "redis:" + r.code + ":" + key
+	var val string
+	var has bool
+	delta, hasDelta := stampede.getDelta(stampedeKey)
+	if hasDelta {
+		var ttl time.Duration
+		val, has, ttl = r.getWithTTL(key)
+		if has && ttl > 0 && refreshEarly(delta, ttl) {
+			has = false
+		}
+	} else {
+		val, has = r.Get(key)
+	}
+	if has {
+		var data interface{}
+		_ = jsoniter.ConfigFastest.Unmarshal([]byte(val), &data)
+		return data
+	}
+	check := func() (interface{}, bool) {
+		val, has := r.Get(key)
+		if !has {
+			return nil, false
+		}
+		var data interface{}
+		_ = jsoniter.ConfigFastest.Unmarshal([]byte(val), &data)
+		return data, true
+	}
+	userVal, _ := stampede.do(r.engine, stampedeKey, check, func() interface{} {
+		start := time.Now()
 		userVal := provider()
+		stampede.setDelta(stampedeKey, time.Since(start))
 		encoded, _ := jsoniter.ConfigFastest.Marshal(userVal)
 		r.Set(key, string(encoded), ttlSeconds)
 		return userVal
-	}
-	var data interface{}
-	_ = jsoniter.ConfigFastest.Unmarshal([]byte(val), &data)
-	return data
+	})
+	return userVal
 }
 
 func (r *RedisCache) PipeLine() *RedisPipeLine {
-	return &RedisPipeLine{ctx: r.client.Context(), pool: r.code, engine: r.engine, pipeLine: r.client.Pipeline()}
+	return &RedisPipeLine{ctx: r.client.Context(), pool: r.code, engine: r.engine, pipeLine: r.client.Pipeline(), cluster: r.cluster,
+		prefix: r.prefix}
 }
 
 func (r *RedisCache) Info(section ...string) string {
@@ -67,7 +99,7 @@ func (r *RedisCache) Info(section ...string) string {
 
 func (r *RedisCache) Get(key string) (value string, has bool) {
 	start := time.Now()
-	val, err := r.client.Get(r.ctx, key).Result()
+	val, err := r.client.Get(r.ctx, r.prefixKey(key)).Result()
 	if err != nil {
 		if err == redis.Nil {
 			err = nil
@@ -86,7 +118,7 @@ func (r *RedisCache) Get(key string) (value string, has bool) {
 
 func (r *RedisCache) Eval(script string, keys []string, args ...interface{}) interface{} {
 	start := time.Now()
-	res, err := r.client.Eval(r.ctx, script, keys, args...).Result()
+	res, err := r.client.Eval(r.ctx, script, redisPrefixKeys(r.prefix, keys), args...).Result()
 	if r.engine.hasRedisLogger {
 		r.fillLogFields("[ORM][REDIS][EVAL]", start, "eval", -1, 1, nil, err)
 	}
@@ -96,7 +128,7 @@ func (r *RedisCache) Eval(script string, keys []string, args ...interface{}) int
 
 func (r *RedisCache) EvalSha(sha1 string, keys []string, args ...interface{}) interface{} {
 	start := time.Now()
-	res, err := r.client.EvalSha(r.ctx, sha1, keys, args...).Result()
+	res, err := r.client.EvalSha(r.ctx, sha1, redisPrefixKeys(r.prefix, keys), args...).Result()
 	if r.engine.hasRedisLogger {
 		r.fillLogFields("[ORM][REDIS][EVALSHA]", start, "evalsha", -1, 1, nil, err)
 	}
@@ -106,7 +138,16 @@ func (r *RedisCache) EvalSha(sha1 string, keys []string, args ...interface{}) in
 
 func (r *RedisCache) ScriptLoad(script string) string {
 	start := time.Now()
-	res, err := r.client.ScriptLoad(r.ctx, script).Result()
+	var res string
+	var err error
+	if r.cluster {
+		res = fmt.Sprintf("%x", sha1.Sum([]byte(script)))
+		err = r.client.(*redis.ClusterClient).ForEachMaster(r.ctx, func(ctx context.Context, client *redis.Client) error {
+			return client.ScriptLoad(ctx, script).Err()
+		})
+	} else {
+		res, err = r.client.ScriptLoad(r.ctx, script).Result()
+	}
 	if r.engine.hasRedisLogger {
 		r.fillLogFields("[ORM][REDIS][SCRIPLOAD]", start, "scriptload", -1, 1, nil, err)
 	}
@@ -116,7 +157,7 @@ func (r *RedisCache) ScriptLoad(script string) string {
 
 func (r *RedisCache) Set(key string, value interface{}, ttlSeconds int) {
 	start := time.Now()
-	_, err := r.client.Set(r.ctx, key, value, time.Duration(ttlSeconds)*time.Second).Result()
+	_, err := r.client.Set(r.ctx, r.prefixKey(key), value, time.Duration(ttlSeconds)*time.Second).Result()
 	if r.engine.hasRedisLogger {
 		r.fillLogFields("[ORM][REDIS][SET]", start, "set", -1, 1,
 			map[string]interface{}{"Key": key, "value": value, "ttl": ttlSeconds}, err)
@@ -124,9 +165,32 @@ func (r *RedisCache) Set(key string, value interface{}, ttlSeconds int) {
 	checkError(err)
 }
 
+func (r *RedisCache) getWithTTL(key string) (value string, has bool, ttl time.Duration) {
+	start := time.Now()
+	pipeline := r.client.Pipeline()
+	get := pipeline.Get(r.ctx, r.prefixKey(key))
+	pttl := pipeline.PTTL(r.ctx, r.prefixKey(key))
+	_, err := pipeline.Exec(r.ctx)
+	if err == redis.Nil {
+		err = nil
+	}
+	misses := 0
+	value, getErr := get.Result()
+	if getErr == redis.Nil {
+		misses = 1
+	} else {
+		has = true
+	}
+	if r.engine.hasRedisLogger {
+		r.fillLogFields("[ORM][REDIS][GET]", start, "get", misses, 1, map[string]interface{}{"Key": key}, err)
+	}
+	checkError(err)
+	return value, has, pttl.Val()
+}
+
 func (r *RedisCache) LPush(key string, values ...interface{}) int64 {
 	start := time.Now()
-	val, err := r.client.LPush(r.ctx, key, values...).Result()
+	val, err := r.client.LPush(r.ctx, r.prefixKey(key), values...).Result()
 	if r.engine.hasRedisLogger {
 		r.fillLogFields("[ORM][REDIS][LPUSH]", start, "lpush", -1, len(values),
 			map[string]interface{}{"Key": key, "values": values}, err)
@@ -137,7 +201,7 @@ func (r *RedisCache) LPush(key string, values ...interface{}) int64 {
 
 func (r *RedisCache) RPush(key string, values ...interface{}) int64 {
 	start := time.Now()
-	val, err := r.client.RPush(r.ctx, key, values...).Result()
+	val, err := r.client.RPush(r.ctx, r.prefixKey(key), values...).Result()
 	if r.engine.hasRedisLogger {
 		r.fillLogFields("[ORM][REDIS][RPUSH]", start, "rpush", -1, len(values),
 			map[string]interface{}{"Key": key, "values": values}, err)
@@ -148,7 +212,7 @@ func (r *RedisCache) RPush(key string, values ...interface{}) int64 {
 
 func (r *RedisCache) LLen(key string) int64 {
 	start := time.Now()
-	val, err := r.client.LLen(r.ctx, key).Result()
+	val, err := r.client.LLen(r.ctx, r.prefixKey(key)).Result()
 	if r.engine.hasRedisLogger {
 		r.fillLogFields("[ORM][REDIS][LLEN]", start, "llen", -1, 1,
 			map[string]interface{}{"Key": key}, err)
@@ -159,7 +223,16 @@ func (r *RedisCache) LLen(key string) int64 {
 
 func (r *RedisCache) Exists(keys ...string) int64 {
 	start := time.Now()
-	val, err := r.client.Exists(r.ctx, keys...).Result()
+	val := int64(0)
+	var err error
+	for _, group := range groupRedisKeysBySlot(r.cluster, redisPrefixKeys(r.prefix, keys)) {
+		var exists int64
+		exists, err = r.client.Exists(r.ctx, group...).Result()
+		if err != nil {
+			break
+		}
+		val += exists
+	}
 	if r.engine.hasRedisLogger {
 		r.fillLogFields("[ORM][REDIS][EXISTS]", start, "exists", -1, 1,
 			map[string]interface{}{"Keys": keys}, err)
@@ -170,7 +243,7 @@ func (r *RedisCache) Exists(keys ...string) int64 {
 
 func (r *RedisCache) Type(key string) string {
 	start := time.Now()
-	val, err := r.client.Type(r.ctx, key).Result()
+	val, err := r.client.Type(r.ctx, r.prefixKey(key)).Result()
 	if r.engine.hasRedisLogger {
 		r.fillLogFields("[ORM][REDIS][TYPE]", start, "type", -1, 1,
 			map[string]interface{}{"Key": key}, err)
@@ -181,7 +254,7 @@ func (r *RedisCache) Type(key string) string {
 
 func (r *RedisCache) LRange(key string, start, stop int64) []string {
 	s := time.Now()
-	val, err := r.client.LRange(r.ctx, key, start, stop).Result()
+	val, err := r.client.LRange(r.ctx, r.prefixKey(key), start, stop).Result()
 	if r.engine.hasRedisLogger {
 		r.fillLogFields("[ORM][REDIS][LRANGE]", s, "lrange", -1, len(val),
 			map[string]interface{}{"Key": key, "start": start, "stop": stop}, err)
@@ -192,7 +265,7 @@ func (r *RedisCache) LRange(key string, start, stop int64) []string {
 
 func (r *RedisCache) LSet(key string, index int64, value interface{}) {
 	start := time.Now()
-	_, err := r.client.LSet(r.ctx, key, index, value).Result()
+	_, err := r.client.LSet(r.ctx, r.prefixKey(key), index, value).Result()
 	if r.engine.hasRedisLogger {
 		r.fillLogFields("[ORM][REDIS][LSET]", start, "lset", -1, 1,
 			map[string]interface{}{"Key": key, "index": index, "value": value}, err)
@@ -202,7 +275,7 @@ func (r *RedisCache) LSet(key string, index int64, value interface{}) {
 
 func (r *RedisCache) RPop(key string) (value string, found bool) {
 	start := time.Now()
-	val, err := r.client.RPop(r.ctx, key).Result()
+	val, err := r.client.RPop(r.ctx, r.prefixKey(key)).Result()
 	if err != nil {
 		if err == redis.Nil {
 			err = nil
@@ -223,7 +296,7 @@ func (r *RedisCache) RPop(key string) (value string, found bool) {
 
 func (r *RedisCache) LRem(key string, count int64, value interface{}) {
 	start := time.Now()
-	_, err := r.client.LRem(r.ctx, key, count, value).Result()
+	_, err := r.client.LRem(r.ctx, r.prefixKey(key), count, value).Result()
 	if r.engine.hasRedisLogger {
 		r.fillLogFields("[ORM][REDIS][LREM]", start, "lrem", -1, 1,
 			map[string]interface{}{"Key": key, "count": count, "value": value}, err)
@@ -233,7 +306,7 @@ func (r *RedisCache) LRem(key string, count int64, value interface{}) {
 
 func (r *RedisCache) Ltrim(key string, start, stop int64) {
 	s := time.Now()
-	_, err := r.client.LTrim(r.ctx, key, start, stop).Result()
+	_, err := r.client.LTrim(r.ctx, r.prefixKey(key), start, stop).Result()
 	if r.engine.hasRedisLogger {
 		r.fillLogFields("[ORM][REDIS][LTRIM]", s, "ltrim", -1, 1,
 			map[string]interface{}{"Key": key, "start": start, "stop": stop}, err)
@@ -243,7 +316,7 @@ func (r *RedisCache) Ltrim(key string, start, stop int64) {
 
 func (r *RedisCache) HSet(key string, values ...interface{}) {
 	start := time.Now()
-	_, err := r.client.HSet(r.ctx, key, values...).Result()
+	_, err := r.client.HSet(r.ctx, r.prefixKey(key), values...).Result()
 	if r.engine.hasRedisLogger {
 		r.fillLogFields("[ORM][REDIS][HSET]", start, "hset", -1, 1,
 			map[string]interface{}{"Key": key, "values": values}, err)
@@ -253,7 +326,7 @@ func (r *RedisCache) HSet(key string, values ...interface{}) {
 
 func (r *RedisCache) HDel(key string, fields ...string) {
 	start := time.Now()
-	_, err := r.client.HDel(r.ctx, key, fields...).Result()
+	_, err := r.client.HDel(r.ctx, r.prefixKey(key), fields...).Result()
 	if r.engine.hasRedisLogger {
 		r.fillLogFields("[ORM][REDIS][HDEL]", start, "hdel", -1, len(fields),
 			map[string]interface{}{"Key": key, "fields": fields}, err)
@@ -263,7 +336,7 @@ func (r *RedisCache) HDel(key string, fields ...string) {
 
 func (r *RedisCache) HMget(key string, fields ...string) map[string]interface{} {
 	start := time.Now()
-	val, err := r.client.HMGet(r.ctx, key, fields...).Result()
+	val, err := r.client.HMGet(r.ctx, r.prefixKey(key), fields...).Result()
 	results := make(map[string]interface{}, len(fields))
 	misses := 0
 	for index, v := range val {
@@ -281,7 +354,7 @@ func (r *RedisCache) HMget(key string, fields ...string) map[string]interface{}
 
 func (r *RedisCache) HGetAll(key string) map[string]string {
 	start := time.Now()
-	val, err := r.client.HGetAll(r.ctx, key).Result()
+	val, err := r.client.HGetAll(r.ctx, r.prefixKey(key)).Result()
 	if r.engine.hasRedisLogger {
 		r.fillLogFields("[ORM][REDIS][HGETALL]", start, "hgetall", -1, 1,
 			map[string]interface{}{"Key": key}, err)
@@ -293,7 +366,7 @@ func (r *RedisCache) HGetAll(key string) map[string]string {
 func (r *RedisCache) HGet(key, field string) (value string, has bool) {
 	misses := 0
 	start := time.Now()
-	val, err := r.client.HGet(r.ctx, key, field).Result()
+	val, err := r.client.HGet(r.ctx, r.prefixKey(key), field).Result()
 	if err == redis.Nil {
 		err = nil
 		misses = 1
@@ -308,7 +381,7 @@ func (r *RedisCache) HGet(key, field string) (value string, has bool) {
 
 func (r *RedisCache) HLen(key string) int64 {
 	start := time.Now()
-	val, err := r.client.HLen(r.ctx, key).Result()
+	val, err := r.client.HLen(r.ctx, r.prefixKey(key)).Result()
 	if r.engine.hasRedisLogger {
 		r.fillLogFields("[ORM][REDIS][HLEN]", start, "hlen", 0, 1,
 			map[string]interface{}{"Key": key}, err)
@@ -319,7 +392,7 @@ func (r *RedisCache) HLen(key string) int64 {
 
 func (r *RedisCache) HIncrBy(key, field string, incr int64) int64 {
 	start := time.Now()
-	val, err := r.client.HIncrBy(r.ctx, key, field, incr).Result()
+	val, err := r.client.HIncrBy(r.ctx, r.prefixKey(key), field, incr).Result()
 	if r.engine.hasRedisLogger {
 		r.fillLogFields("[ORM][REDIS][HINCRBY]", start, "hincrby", -1, 1,
 			map[string]interface{}{"Key": key, "incr": incr}, err)
@@ -330,7 +403,7 @@ func (r *RedisCache) HIncrBy(key, field string, incr int64) int64 {
 
 func (r *RedisCache) IncrBy(key string, incr int64) int64 {
 	start := time.Now()
-	val, err := r.client.IncrBy(r.ctx, key, incr).Result()
+	val, err := r.client.IncrBy(r.ctx, r.prefixKey(key), incr).Result()
 	if r.engine.hasRedisLogger {
 		r.fillLogFields("[ORM][REDIS][INCRBY]", start, "incrby", -1, 1,
 			map[string]interface{}{"Key": key, "incr": incr}, err)
@@ -341,7 +414,7 @@ func (r *RedisCache) IncrBy(key string, incr int64) int64 {
 
 func (r *RedisCache) Incr(key string) int64 {
 	start := time.Now()
-	val, err := r.client.Incr(r.ctx, key).Result()
+	val, err := r.client.Incr(r.ctx, r.prefixKey(key)).Result()
 	if r.engine.hasRedisLogger {
 		r.fillLogFields("[ORM][REDIS][INC]", start, "incr", -1, 1,
 			map[string]interface{}{"Key": key}, err)
@@ -352,7 +425,7 @@ func (r *RedisCache) Incr(key string) int64 {
 
 func (r *RedisCache) Expire(key string, expiration time.Duration) bool {
 	start := time.Now()
-	val, err := r.client.Expire(r.ctx, key, expiration).Result()
+	val, err := r.client.Expire(r.ctx, r.prefixKey(key), expiration).Result()
 	if r.engine.hasRedisLogger {
 		r.fillLogFields("[ORM][REDIS][EXPIRE]", start, "expire", -1, 1,
 			map[string]interface{}{"Key": key, "expiration": expiration}, err)
@@ -363,7 +436,7 @@ func (r *RedisCache) Expire(key string, expiration time.Duration) bool {
 
 func (r *RedisCache) ZAdd(key string, members ...*redis.Z) int64 {
 	start := time.Now()
-	val, err := r.client.ZAdd(r.ctx, key, members...).Result()
+	val, err := r.client.ZAdd(r.ctx, r.prefixKey(key), members...).Result()
 	if r.engine.hasRedisLogger {
 		r.fillLogFields("[ORM][REDIS][ZADD]", start, "zadd", -1, len(members),
 			map[string]interface{}{"Key": key, "members": len(members)}, err)
@@ -374,7 +447,7 @@ func (r *RedisCache) ZAdd(key string, members ...*redis.Z) int64 {
 
 func (r *RedisCache) ZRevRange(key string, start, stop int64) []string {
 	startTime := time.Now()
-	val, err := r.client.ZRevRange(r.ctx, key, start, stop).Result()
+	val, err := r.client.ZRevRange(r.ctx, r.prefixKey(key), start, stop).Result()
 	if r.engine.hasRedisLogger {
 		r.fillLogFields("[ORM][REDIS][ZREVRANGE]", startTime, "zrevrange", -1, 1,
 			map[string]interface{}{"Key": key, "start": start, "stop": stop}, err)
@@ -385,7 +458,7 @@ func (r *RedisCache) ZRevRange(key string, start, stop int64) []string {
 
 func (r *RedisCache) ZRevRangeWithScores(key string, start, stop int64) []redis.Z {
 	startTime := time.Now()
-	val, err := r.client.ZRevRangeWithScores(r.ctx, key, start, stop).Result()
+	val, err := r.client.ZRevRangeWithScores(r.ctx, r.prefixKey(key), start, stop).Result()
 	if r.engine.hasRedisLogger {
 		r.fillLogFields("[ORM][REDIS][ZREVRANGEWITHSCORES]", startTime, "zrevrangewithscores", -1, 1,
 			map[string]interface{}{"Key": key, "start": start, "stop": stop}, err)
@@ -396,7 +469,7 @@ func (r *RedisCache) ZRevRangeWithScores(key string, start, stop int64) []redis.
 
 func (r *RedisCache) ZRangeWithScores(key string, start, stop int64) []redis.Z {
 	startTime := time.Now()
-	val, err := r.client.ZRangeWithScores(r.ctx, key, start, stop).Result()
+	val, err := r.client.ZRangeWithScores(r.ctx, r.prefixKey(key), start, stop).Result()
 	if r.engine.hasRedisLogger {
 		r.fillLogFields("[ORM][REDIS][ZRANGEWITHSCORES]", startTime, "zrangewithscores", -1, 1,
 			map[string]interface{}{"Key": key, "start": start, "stop": stop}, err)
@@ -407,7 +480,7 @@ func (r *RedisCache) ZRangeWithScores(key string, start, stop int64) []redis.Z {
 
 func (r *RedisCache) ZCard(key string) int64 {
 	start := time.Now()
-	val, err := r.client.ZCard(r.ctx, key).Result()
+	val, err := r.client.ZCard(r.ctx, r.prefixKey(key)).Result()
 	if r.engine.hasRedisLogger {
 		r.fillLogFields("[ORM][REDIS][ZCARD]", start, "zcard", -1, 1,
 			map[string]interface{}{"Key": key}, err)
@@ -418,7 +491,7 @@ func (r *RedisCache) ZCard(key string) int64 {
 
 func (r *RedisCache) ZCount(key string, min, max string) int64 {
 	start := time.Now()
-	val, err := r.client.ZCount(r.ctx, key, min, max).Result()
+	val, err := r.client.ZCount(r.ctx, r.prefixKey(key), min, max).Result()
 	if r.engine.hasRedisLogger {
 		r.fillLogFields("[ORM][REDIS][ZCOUNT]", start, "zcount", -1, 1,
 			map[string]interface{}{"Key": key, "min": min, "max": max}, err)
@@ -429,7 +502,7 @@ func (r *RedisCache) ZCount(key string, min, max string) int64 {
 
 func (r *RedisCache) ZScore(key, member string) float64 {
 	start := time.Now()
-	val, err := r.client.ZScore(r.ctx, key, member).Result()
+	val, err := r.client.ZScore(r.ctx, r.prefixKey(key), member).Result()
 	if r.engine.hasRedisLogger {
 		r.fillLogFields("[ORM][REDIS][ZSCORE]", start, "zscore", -1, 1,
 			map[string]interface{}{"Key": key, "member": member}, err)
@@ -438,9 +511,78 @@ func (r *RedisCache) ZScore(key, member string) float64 {
 	return val
 }
 
+func (r *RedisCache) ZIncrBy(key string, increment float64, member string) float64 {
+	start := time.Now()
+	val, err := r.client.ZIncrBy(r.ctx, r.prefixKey(key), increment, member).Result()
+	if r.engine.hasRedisLogger {
+		r.fillLogFields("[ORM][REDIS][ZINCRBY]", start, "zincrby", -1, 1,
+			map[string]interface{}{"Key": key, "member": member, "increment": increment}, err)
+	}
+	checkError(err)
+	return val
+}
+
+func (r *RedisCache) ZRevRank(key, member string) (rank int64, has bool) {
+	misses := 0
+	start := time.Now()
+	rank, err := r.client.ZRevRank(r.ctx, r.prefixKey(key), member).Result()
+	if err == redis.Nil {
+		err = nil
+		misses = 1
+	}
+	if r.engine.hasRedisLogger {
+		r.fillLogFields("[ORM][REDIS][ZREVRANK]", start, "zrevrank", misses, 1,
+			map[string]interface{}{"Key": key, "member": member}, err)
+	}
+	checkError(err)
+	return rank, misses == 0
+}
+
+func (r *RedisCache) ZRem(key string, members ...interface{}) int64 {
+	start := time.Now()
+	val, err := r.client.ZRem(r.ctx, r.prefixKey(key), members...).Result()
+	if r.engine.hasRedisLogger {
+		r.fillLogFields("[ORM][REDIS][ZREM]", start, "zrem", -1, len(members),
+			map[string]interface{}{"Key": key, "members": len(members)}, err)
+	}
+	checkError(err)
+	return val
+}
+
+func (r *RedisCache) PFAdd(key string, members ...interface{}) bool {
+	start := time.Now()
+	val, err := r.client.PFAdd(r.ctx, r.prefixKey(key), members...).Result()
+	if r.engine.hasRedisLogger {
+		r.fillLogFields("[ORM][REDIS][PFADD]", start, "pfadd", -1, len(members),
+			map[string]interface{}{"Key": key, "members": len(members)}, err)
+	}
+	checkError(err)
+	return val == 1
+}
+
+func (r *RedisCache) PFCount(keys ...string) int64 {
+	start := time.Now()
+	val, err := r.client.PFCount(r.ctx, redisPrefixKeys(r.prefix, keys)...).Result()
+	if r.engine.hasRedisLogger {
+		r.fillLogFields("[ORM][REDIS][PFCOUNT]", start, "pfcount", -1, len(keys),
+			map[string]interface{}{"Keys": keys}, err)
+	}
+	checkError(err)
+	return val
+}
+
 func (r *RedisCache) MSet(pairs ...interface{}) {
 	start := time.Now()
-	_, err := r.client.MSet(r.ctx, pairs...).Result()
+	var err error
+	if r.cluster {
+		pipeline := r.client.Pipeline()
+		for i := 0; i < len(pairs); i += 2 {
+			pipeline.Set(r.ctx, r.prefixKey(pairs[i].(string)), pairs[i+1], 0)
+		}
+		_, err = pipeline.Exec(r.ctx)
+	} else {
+		_, err = r.client.MSet(r.ctx, r.prefixPairs(pairs)...).Result()
+	}
 	if r.engine.hasRedisLogger {
 		r.fillLogFields("[ORM][REDIS][MSET]", start, "mset", -1, len(pairs),
 			map[string]interface{}{"Pairs": pairs}, err)
@@ -448,15 +590,44 @@ func (r *RedisCache) MSet(pairs ...interface{}) {
 	checkError(err)
 }
 
+func (r *RedisCache) mSetWithTTL(ttl time.Duration, pairs ...interface{}) {
+	if ttl == 0 {
+		r.MSet(pairs...)
+		return
+	}
+	start := time.Now()
+	pipeline := r.client.Pipeline()
+	for i := 0; i < len(pairs); i += 2 {
+		if ttl == redisSetNX {
+			pipeline.SetNX(r.ctx, r.prefixKey(pairs[i].(string)), pairs[i+1], 0)
+		} else {
+			pipeline.Set(r.ctx, r.prefixKey(pairs[i].(string)), pairs[i+1], ttl)
+		}
+	}
+	_, err := pipeline.Exec(r.ctx)
+	if r.engine.hasRedisLogger {
+		r.fillLogFields("[ORM][REDIS][MSET]", start, "mset", -1, len(pairs),
+			map[string]interface{}{"Pairs": pairs, "ttl": ttl}, err)
+	}
+	checkError(err)
+}
+
 func (r *RedisCache) MGet(keys ...string) map[string]interface{} {
 	start := time.Now()
-	val, err := r.client.MGet(r.ctx, keys...).Result()
 	results := make(map[string]interface{}, len(keys))
 	misses := 0
-	for index, v := range val {
-		results[keys[index]] = v
-		if v == nil {
-			misses++
+	var err error
+	for _, group := range groupRedisKeysBySlot(r.cluster, redisPrefixKeys(r.prefix, keys)) {
+		var val []interface{}
+		val, err = r.client.MGet(r.ctx, group...).Result()
+		if err != nil {
+			break
+		}
+		for index, v := range val {
+			results[group[index][len(r.prefix):]] = v
+			if v == nil {
+				misses++
+			}
 		}
 	}
 	if r.engine.hasRedisLogger {
@@ -469,7 +640,7 @@ func (r *RedisCache) MGet(keys ...string) map[string]interface{} {
 
 func (r *RedisCache) SAdd(key string, members ...interface{}) int64 {
 	start := time.Now()
-	val, err := r.client.SAdd(r.ctx, key, members...).Result()
+	val, err := r.client.SAdd(r.ctx, r.prefixKey(key), members...).Result()
 	if r.engine.hasRedisLogger {
 		r.fillLogFields("[ORM][REDIS][SADD]", start, "sadd", -1, len(members),
 			map[string]interface{}{"Key": key, "members": len(members)}, err)
@@ -480,7 +651,7 @@ func (r *RedisCache) SAdd(key string, members ...interface{}) int64 {
 
 func (r *RedisCache) SCard(key string) int64 {
 	start := time.Now()
-	val, err := r.client.SCard(r.ctx, key).Result()
+	val, err := r.client.SCard(r.ctx, r.prefixKey(key)).Result()
 	if r.engine.hasRedisLogger {
 		r.fillLogFields("[ORM][REDIS][SCARD]", start, "scard", -1, 1,
 			map[string]interface{}{"Key": key}, err)
@@ -491,7 +662,7 @@ func (r *RedisCache) SCard(key string) int64 {
 
 func (r *RedisCache) SPop(key string) (string, bool) {
 	start := time.Now()
-	val, err := r.client.SPop(r.ctx, key).Result()
+	val, err := r.client.SPop(r.ctx, r.prefixKey(key)).Result()
 	found := true
 	if err == redis.Nil {
 		err = nil
@@ -507,7 +678,7 @@ func (r *RedisCache) SPop(key string) (string, bool) {
 
 func (r *RedisCache) SPopN(key string, max int64) []string {
 	start := time.Now()
-	val, err := r.client.SPopN(r.ctx, key, max).Result()
+	val, err := r.client.SPopN(r.ctx, r.prefixKey(key), max).Result()
 	if r.engine.hasRedisLogger {
 		r.fillLogFields("[ORM][REDIS][SPOPN]", start, "spopn", -1, 1,
 			map[string]interface{}{"Key": key, "max": max}, err)
@@ -518,7 +689,13 @@ func (r *RedisCache) SPopN(key string, max int64) []string {
 
 func (r *RedisCache) Del(keys ...string) {
 	start := time.Now()
-	_, err := r.client.Del(r.ctx, keys...).Result()
+	var err error
+	for _, group := range groupRedisKeysBySlot(r.cluster, redisPrefixKeys(r.prefix, keys)) {
+		_, err = r.client.Del(r.ctx, group...).Result()
+		if err != nil {
+			break
+		}
+	}
 	if r.engine.hasRedisLogger {
 		r.fillLogFields("[ORM][REDIS][DEL]", start, "del", -1, len(keys),
 			map[string]interface{}{"Keys": keys}, err)
@@ -530,9 +707,9 @@ func (r *RedisCache) XTrim(stream string, maxLen int64, approx bool) (deleted in
 	start := time.Now()
 	var err error
 	if approx {
-		deleted, err = r.client.XTrimApprox(r.ctx, stream, maxLen).Result()
+		deleted, err = r.client.XTrimApprox(r.ctx, r.streamKey(stream), maxLen).Result()
 	} else {
-		deleted, err = r.client.XTrim(r.ctx, stream, maxLen).Result()
+		deleted, err = r.client.XTrim(r.ctx, r.streamKey(stream), maxLen).Result()
 	}
 	if r.engine.hasStreamsLogger {
 		r.fillStreamsLogFields("[ORM][STREAMS][XTRIM]", start, "xtrim",
@@ -544,7 +721,7 @@ func (r *RedisCache) XTrim(stream string, maxLen int64, approx bool) (deleted in
 
 func (r *RedisCache) XRange(stream, start, stop string, count int64) []redis.XMessage {
 	s := time.Now()
-	deleted, err := r.client.XRangeN(r.ctx, stream, start, stop, count).Result()
+	deleted, err := r.client.XRangeN(r.ctx, r.streamKey(stream), start, stop, count).Result()
 	if r.engine.hasStreamsLogger {
 		r.fillStreamsLogFields("[ORM][STREAMS][XRANGE]", s, "xrange",
 			map[string]interface{}{"stream": stream, "start": start, "stop": stop, "count": count}, err)
@@ -555,7 +732,7 @@ func (r *RedisCache) XRange(stream, start, stop string, count int64) []redis.XMe
 
 func (r *RedisCache) XRevRange(stream, start, stop string, count int64) []redis.XMessage {
 	s := time.Now()
-	deleted, err := r.client.XRevRangeN(r.ctx, stream, start, stop, count).Result()
+	deleted, err := r.client.XRevRangeN(r.ctx, r.streamKey(stream), start, stop, count).Result()
 	if r.engine.hasStreamsLogger {
 		r.fillStreamsLogFields("[ORM][STREAMS][XREVRANGE]", s, "xrevrange",
 			map[string]interface{}{"stream": stream, "start": start, "stop": stop, "count": count}, err)
@@ -566,7 +743,7 @@ func (r *RedisCache) XRevRange(stream, start, stop string, count int64) []redis.
 
 func (r *RedisCache) XInfoStream(stream string) *redis.XInfoStream {
 	start := time.Now()
-	info, err := r.client.XInfoStream(r.ctx, stream).Result()
+	info, err := r.client.XInfoStream(r.ctx, r.streamKey(stream)).Result()
 	if r.engine.hasStreamsLogger {
 		r.fillStreamsLogFields("[ORM][STREAMS][XINFO]", start, "xinfo",
 			map[string]interface{}{"stream": stream}, err)
@@ -577,7 +754,7 @@ func (r *RedisCache) XInfoStream(stream string) *redis.XInfoStream {
 
 func (r *RedisCache) XInfoGroups(stream string) []redis.XInfoGroup {
 	start := time.Now()
-	info, err := r.client.XInfoGroups(r.ctx, stream).Result()
+	info, err := r.client.XInfoGroups(r.ctx, r.streamKey(stream)).Result()
 	if err != nil && err.Error() == "ERR no such key" {
 		if r.engine.hasStreamsLogger {
 			r.fillStreamsLogFields("[ORM][STREAMS][XINFO]", start, "xinfo",
@@ -595,7 +772,7 @@ func (r *RedisCache) XInfoGroups(stream string) []redis.XInfoGroup {
 
 func (r *RedisCache) XGroupCreate(stream, group, start string) (key string, exists bool) {
 	s := time.Now()
-	res, err := r.client.XGroupCreate(r.ctx, stream, group, start).Result()
+	res, err := r.client.XGroupCreate(r.ctx, r.streamKey(stream), group, start).Result()
 	if err != nil && strings.HasPrefix(err.Error(), "BUSYGROUP") {
 		if r.engine.hasStreamsLogger {
 			r.fillStreamsLogFields("[ORM][STREAMS][XGROUP]", s, "xgroup",
@@ -613,7 +790,7 @@ func (r *RedisCache) XGroupCreate(stream, group, start string) (key string, exis
 
 func (r *RedisCache) XGroupCreateMkStream(stream, group, start string) (key string, exists bool) {
 	s := time.Now()
-	res, err := r.client.XGroupCreateMkStream(r.ctx, stream, group, start).Result()
+	res, err := r.client.XGroupCreateMkStream(r.ctx, r.streamKey(stream), group, start).Result()
 	created := false
 	if err != nil && strings.HasPrefix(err.Error(), "BUSYGROUP") {
 		created = true
@@ -630,7 +807,7 @@ func (r *RedisCache) XGroupCreateMkStream(stream, group, start string) (key stri
 
 func (r *RedisCache) XGroupDestroy(stream, group string) int64 {
 	start := time.Now()
-	res, err := r.client.XGroupDestroy(r.ctx, stream, group).Result()
+	res, err := r.client.XGroupDestroy(r.ctx, r.streamKey(stream), group).Result()
 	if r.engine.hasStreamsLogger {
 		r.fillStreamsLogFields("[ORM][STREAMS][XGROUP]", start, "xgroup",
 			map[string]interface{}{"arg": "destroy", "stream": stream, "group": group}, err)
@@ -641,10 +818,15 @@ func (r *RedisCache) XGroupDestroy(stream, group string) int64 {
 
 func (r *RedisCache) XRead(a *redis.XReadArgs) []redis.XStream {
 	start := time.Now()
-	info, err := r.client.XRead(r.ctx, a).Result()
+	args := *a
+	args.Streams = r.streamArguments(a.Streams)
+	info, err := r.client.XRead(r.ctx, &args).Result()
 	if err == redis.Nil {
 		err = nil
 	}
+	for i := range info {
+		info[i].Stream = r.streamName(info[i].Stream)
+	}
 	if r.engine.hasStreamsLogger {
 		r.fillStreamsLogFields("[ORM][STREAMS][XREAD]", start, "xread",
 			map[string]interface{}{"arg": a}, err)
@@ -655,7 +837,7 @@ func (r *RedisCache) XRead(a *redis.XReadArgs) []redis.XStream {
 
 func (r *RedisCache) XDel(stream string, ids ...string) int64 {
 	s := time.Now()
-	deleted, err := r.client.XDel(r.ctx, stream, ids...).Result()
+	deleted, err := r.client.XDel(r.ctx, r.streamKey(stream), ids...).Result()
 	if r.engine.hasStreamsLogger {
 		r.fillStreamsLogFields("[ORM][STREAMS][XDEL]", s, "xdel",
 			map[string]interface{}{"stream": stream, "ids": ids}, err)
@@ -666,7 +848,7 @@ func (r *RedisCache) XDel(stream string, ids ...string) int64 {
 
 func (r *RedisCache) XGroupDelConsumer(stream, group, consumer string) int64 {
 	start := time.Now()
-	deleted, err := r.client.XGroupDelConsumer(r.ctx, stream, group, consumer).Result()
+	deleted, err := r.client.XGroupDelConsumer(r.ctx, r.streamKey(stream), group, consumer).Result()
 	if r.engine.hasStreamsLogger {
 		r.fillStreamsLogFields("[ORM][STREAMS][XDEL]", start, "XGROUP",
 			map[string]interface{}{"stream": stream, "group": group, "consumer": "consumer", "action": "delete consumer"}, err)
@@ -677,10 +859,15 @@ func (r *RedisCache) XGroupDelConsumer(stream, group, consumer string) int64 {
 
 func (r *RedisCache) XReadGroup(a *redis.XReadGroupArgs) (streams []redis.XStream) {
 	start := time.Now()
-	streams, err := r.client.XReadGroup(r.ctx, a).Result()
+	args := *a
+	args.Streams = r.streamArguments(a.Streams)
+	streams, err := r.client.XReadGroup(r.ctx, &args).Result()
 	if err == redis.Nil {
 		err = nil
 	}
+	for i := range streams {
+		streams[i].Stream = r.streamName(streams[i].Stream)
+	}
 	if r.engine.hasStreamsLogger {
 		r.fillStreamsLogFields("[ORM][STREAMS][XREADGROUP]", start, "xreadgroup",
 			map[string]interface{}{"consumer": a.Consumer, "group": a.Group, "count": a.Count, "block": a.Block,
@@ -692,7 +879,7 @@ func (r *RedisCache) XReadGroup(a *redis.XReadGroupArgs) (streams []redis.XStrea
 
 func (r *RedisCache) XPending(stream, group string) *redis.XPending {
 	start := time.Now()
-	res, err := r.client.XPending(r.ctx, stream, group).Result()
+	res, err := r.client.XPending(r.ctx, r.streamKey(stream), group).Result()
 	if r.engine.hasStreamsLogger {
 		r.fillStreamsLogFields("[ORM][STREAMS][XPENDING]", start, "xpending",
 			map[string]interface{}{"stream": stream, "group": group}, err)
@@ -703,7 +890,9 @@ func (r *RedisCache) XPending(stream, group string) *redis.XPending {
 
 func (r *RedisCache) XPendingExt(a *redis.XPendingExtArgs) []redis.XPendingExt {
 	start := time.Now()
-	res, err := r.client.XPendingExt(r.ctx, a).Result()
+	args := *a
+	args.Stream = r.streamKey(a.Stream)
+	res, err := r.client.XPendingExt(r.ctx, &args).Result()
 	if r.engine.hasStreamsLogger {
 		r.fillStreamsLogFields("[ORM][STREAMS][XPENDING]", start, "xpending",
 			map[string]interface{}{"group": a.Group, "stream": a.Stream, "consumer": a.Consumer, "count": a.Count,
@@ -714,7 +903,7 @@ func (r *RedisCache) XPendingExt(a *redis.XPendingExtArgs) []redis.XPendingExt {
 }
 
 func (r *RedisCache) xAdd(stream string, values interface{}) (id string) {
-	a := &redis.XAddArgs{Stream: stream, ID: "*", Values: values}
+	a := &redis.XAddArgs{Stream: r.streamKey(stream), ID: "*", Values: values}
 	start := time.Now()
 	id, err := r.client.XAdd(r.ctx, a).Result()
 	if r.engine.hasStreamsLogger {
@@ -727,7 +916,7 @@ func (r *RedisCache) xAdd(stream string, values interface{}) (id string) {
 
 func (r *RedisCache) XLen(stream string) int64 {
 	start := time.Now()
-	l, err := r.client.XLen(r.ctx, stream).Result()
+	l, err := r.client.XLen(r.ctx, r.streamKey(stream)).Result()
 	if r.engine.hasStreamsLogger {
 		r.fillStreamsLogFields("[ORM][STREAMS][XLEN]", start, "xlen",
 			map[string]interface{}{"stream": stream}, err)
@@ -738,7 +927,9 @@ func (r *RedisCache) XLen(stream string) int64 {
 
 func (r *RedisCache) XClaim(a *redis.XClaimArgs) []redis.XMessage {
 	start := time.Now()
-	res, err := r.client.XClaim(r.ctx, a).Result()
+	args := *a
+	args.Stream = r.streamKey(a.Stream)
+	res, err := r.client.XClaim(r.ctx, &args).Result()
 	if r.engine.hasStreamsLogger {
 		r.fillStreamsLogFields("[ORM][STREAMS][XCLAIM]", start, "xclaim",
 			map[string]interface{}{"arg": a}, err)
@@ -749,7 +940,9 @@ func (r *RedisCache) XClaim(a *redis.XClaimArgs) []redis.XMessage {
 
 func (r *RedisCache) XClaimJustID(a *redis.XClaimArgs) []string {
 	start := time.Now()
-	res, err := r.client.XClaimJustID(r.ctx, a).Result()
+	args := *a
+	args.Stream = r.streamKey(a.Stream)
+	res, err := r.client.XClaimJustID(r.ctx, &args).Result()
 	if r.engine.hasStreamsLogger {
 		r.fillStreamsLogFields("[ORM][STREAMS][XCLAIM]", start, "xclaim",
 			map[string]interface{}{"arg": a, "justid": true}, err)
@@ -760,7 +953,7 @@ func (r *RedisCache) XClaimJustID(a *redis.XClaimArgs) []string {
 
 func (r *RedisCache) XAck(stream, group string, ids ...string) int64 {
 	start := time.Now()
-	res, err := r.client.XAck(r.ctx, stream, group, ids...).Result()
+	res, err := r.client.XAck(r.ctx, r.streamKey(stream), group, ids...).Result()
 	if r.engine.hasStreamsLogger {
 		r.fillStreamsLogFields("[ORM][STREAMS][XACK]", start, "xack",
 			map[string]interface{}{"stream": stream, "group": group, "ids": ids}, err)
@@ -771,7 +964,14 @@ func (r *RedisCache) XAck(stream, group string, ids ...string) int64 {
 
 func (r *RedisCache) FlushDB() {
 	start := time.Now()
-	_, err := r.client.FlushDB(r.ctx).Result()
+	var err error
+	if r.cluster {
+		err = r.client.(*redis.ClusterClient).ForEachMaster(r.ctx, func(ctx context.Context, client *redis.Client) error {
+			return client.FlushDB(ctx).Err()
+		})
+	} else {
+		_, err = r.client.FlushDB(r.ctx).Result()
+	}
 	if r.engine.hasRedisLogger {
 		r.fillLogFields("[ORM][REDIS][FLUSHDB]", start, "flushdb", -1, 1, nil, err)
 	}
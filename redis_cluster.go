@@ -0,0 +1,84 @@
+package orm
+
+import (
+	"strings"
+)
+
+const redisClusterSlots = 16384
+const redisClusterStreamsTag = "{orm-streams}"
+
+func redisKeySlot(key string) uint16 {
+	start := strings.IndexByte(key, '{')
+	if start >= 0 {
+		end := strings.IndexByte(key[start+1:], '}')
+		if end > 0 {
+			key = key[start+1 : start+1+end]
+		}
+	}
+	crc := uint16(0)
+	for i := 0; i < len(key); i++ {
+		crc ^= uint16(key[i]) << 8
+		for j := 0; j < 8; j++ {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc % redisClusterSlots
+}
+
+func redisKeyHashTag(key string) string {
+	return "{" + key + "}"
+}
+
+func groupRedisKeysBySlot(cluster bool, keys []string) [][]string {
+	if !cluster || len(keys) < 2 {
+		return [][]string{keys}
+	}
+	slots := make(map[uint16]int)
+	groups := make([][]string, 0)
+	for _, key := range keys {
+		slot := redisKeySlot(key)
+		index, has := slots[slot]
+		if !has {
+			index = len(groups)
+			slots[slot] = index
+			groups = append(groups, make([]string, 0))
+		}
+		groups[index] = append(groups[index], key)
+	}
+	return groups
+}
+
+func redisStreamKey(cluster bool, stream string) string {
+	if !cluster || strings.HasPrefix(stream, redisClusterStreamsTag) {
+		return stream
+	}
+	return redisClusterStreamsTag + stream
+}
+
+func (r *RedisCache) streamKey(stream string) string {
+	return r.prefix + redisStreamKey(r.cluster, stream)
+}
+
+func (r *RedisCache) streamArguments(streams []string) []string {
+	if !r.cluster && r.prefix == "" {
+		return streams
+	}
+	arguments := make([]string, len(streams))
+	copy(arguments, streams)
+	for i := 0; i < len(streams)/2; i++ {
+		arguments[i] = r.streamKey(streams[i])
+	}
+	return arguments
+}
+
+func (r *RedisCache) streamName(key string) string {
+	key = key[len(r.prefix):]
+	if !r.cluster {
+		return key
+	}
+	return strings.TrimPrefix(key, redisClusterStreamsTag)
+}
@@ -0,0 +1,48 @@
+package orm
+
+import (
+	"testing"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+)
+
+type redisClusterEntity struct {
+	ORM  `orm:"redisCache=cluster"`
+	ID   uint
+	Name string
+}
+
+func TestRedisClusterKeys(t *testing.T) {
+	assert.Equal(t, uint16(12182), redisKeySlot("foo"))
+	assert.Equal(t, uint16(12739), redisKeySlot("123456789"))
+	assert.Equal(t, redisKeySlot("user1000"), redisKeySlot("{user1000}.following"))
+	assert.Equal(t, redisKeySlot("bar"), redisKeySlot("foo{bar}zap"))
+	assert.Equal(t, redisKeySlot("foo{}{bar}"), redisKeySlot("foo{}{bar}"))
+	assert.NotEqual(t, redisKeySlot("bar"), redisKeySlot("foo{}{bar}"))
+
+	groups := groupRedisKeysBySlot(true, []string{"{a}:1", "{b}:1", "{a}:2"})
+	assert.Equal(t, [][]string{{"{a}:1", "{a}:2"}, {"{b}:1"}}, groups)
+	groups = groupRedisKeysBySlot(false, []string{"{a}:1", "{b}:1"})
+	assert.Equal(t, [][]string{{"{a}:1", "{b}:1"}}, groups)
+
+	r := &RedisCache{cluster: true}
+	assert.Equal(t, "{orm-streams}stream-1", r.streamKey("stream-1"))
+	assert.Equal(t, "{orm-streams}stream-1", r.streamKey("{orm-streams}stream-1"))
+	assert.Equal(t, "stream-1", r.streamName("{orm-streams}stream-1"))
+	assert.Equal(t, []string{"{orm-streams}a", "{orm-streams}b", "0", ">"}, r.streamArguments([]string{"a", "b", "0", ">"}))
+	r = &RedisCache{}
+	assert.Equal(t, "stream-1", r.streamKey("stream-1"))
+
+	registry := &Registry{}
+	registry.RegisterMySQLPool("root:root@tcp(localhost:3311)/test")
+	registry.RegisterRedisCluster([]string{"localhost:7001", "localhost:7002"}, "cluster")
+	registry.RegisterEntity(&redisClusterEntity{})
+	validatedRegistry, err := registry.Validate()
+	assert.Nil(t, err)
+	assert.True(t, registry.redisServers["cluster"].cluster)
+	_, isCluster := registry.redisServers["cluster"].client.(*redis.ClusterClient)
+	assert.True(t, isCluster)
+	schema := validatedRegistry.GetTableSchemaForEntity(&redisClusterEntity{}).(*tableSchema)
+	assert.Regexp(t, `^\{[a-f0-9]{5}\}:1$`, schema.getCacheKey(1))
+}
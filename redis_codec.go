@@ -0,0 +1,286 @@
+package orm
+
+import (
+	"bytes"
+	"compress/flate"
+	"encoding/binary"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+
+	jsoniter "github.com/json-iterator/go"
+)
+
+const redisCodecFlagCompressed = 1
+const redisCodecMaxID = 31
+
+type RedisCodec interface {
+	ID() byte
+	Name() string
+	Encode(row []interface{}) []byte
+	Decode(data []byte) ([]interface{}, error)
+}
+
+type redisCodecConfig struct {
+	codec         RedisCodec
+	compressAbove int
+}
+
+type redisCodecSetting struct {
+	codec         string
+	compressAbove int
+}
+
+type binaryRedisCodec struct{}
+
+func (c *binaryRedisCodec) ID() byte {
+	return 1
+}
+
+func (c *binaryRedisCodec) Name() string {
+	return "binary"
+}
+
+func (c *binaryRedisCodec) Encode(row []interface{}) []byte {
+	encoded := make([]byte, 0, len(row)*4)
+	buffer := make([]byte, binary.MaxVarintLen64)
+	for _, value := range row {
+		switch v := value.(type) {
+		case nil:
+			encoded = append(encoded, 0)
+		case uint64:
+			encoded = append(encoded, 1)
+			encoded = append(encoded, buffer[:binary.PutUvarint(buffer, v)]...)
+		case int64:
+			encoded = append(encoded, 2)
+			encoded = append(encoded, buffer[:binary.PutVarint(buffer, v)]...)
+		case string:
+			encoded = append(encoded, 3)
+			encoded = append(encoded, buffer[:binary.PutUvarint(buffer, uint64(len(v)))]...)
+			encoded = append(encoded, v...)
+		case bool:
+			if v {
+				encoded = append(encoded, 4)
+			} else {
+				encoded = append(encoded, 5)
+			}
+		case float64:
+			encoded = append(encoded, 6)
+			binary.LittleEndian.PutUint64(buffer, math.Float64bits(v))
+			encoded = append(encoded, buffer[:8]...)
+		default:
+			panic(fmt.Errorf("unsupported redis codec value type %T", value))
+		}
+	}
+	return encoded
+}
+
+func (c *binaryRedisCodec) Decode(data []byte) ([]interface{}, error) {
+	row := make([]interface{}, 0)
+	for i := 0; i < len(data); {
+		kind := data[i]
+		i++
+		switch kind {
+		case 0:
+			row = append(row, nil)
+		case 1:
+			v, n := binary.Uvarint(data[i:])
+			if n <= 0 {
+				return nil, fmt.Errorf("invalid binary redis value")
+			}
+			row = append(row, v)
+			i += n
+		case 2:
+			v, n := binary.Varint(data[i:])
+			if n <= 0 {
+				return nil, fmt.Errorf("invalid binary redis value")
+			}
+			row = append(row, v)
+			i += n
+		case 3:
+			l, n := binary.Uvarint(data[i:])
+			if n <= 0 || i+n+int(l) > len(data) {
+				return nil, fmt.Errorf("invalid binary redis value")
+			}
+			i += n
+			row = append(row, string(data[i:i+int(l)]))
+			i += int(l)
+		case 4:
+			row = append(row, true)
+		case 5:
+			row = append(row, false)
+		case 6:
+			if i+8 > len(data) {
+				return nil, fmt.Errorf("invalid binary redis value")
+			}
+			row = append(row, math.Float64frombits(binary.LittleEndian.Uint64(data[i:i+8])))
+			i += 8
+		default:
+			return nil, fmt.Errorf("invalid binary redis value")
+		}
+	}
+	return row, nil
+}
+
+func encodeRedisRow(config *redisCodecConfig, row []interface{}) string {
+	if config == nil {
+		encoded, _ := jsoniter.ConfigFastest.Marshal(row)
+		return string(encoded)
+	}
+	data := config.codec.Encode(row)
+	flags := byte(0)
+	if config.compressAbove > 0 && len(data) > config.compressAbove {
+		var compressed bytes.Buffer
+		writer, _ := flate.NewWriter(&compressed, flate.BestSpeed)
+		_, _ = writer.Write(data)
+		_ = writer.Close()
+		data = compressed.Bytes()
+		flags |= redisCodecFlagCompressed
+	}
+	encoded := make([]byte, 0, len(data)+2)
+	encoded = append(encoded, config.codec.ID(), flags)
+	return string(append(encoded, data...))
+}
+
+func decodeRedisRow(registry *validatedRegistry, schema *tableSchema, value string) []interface{} {
+	if value == "" || value[0] > redisCodecMaxID {
+		decoded := make([]interface{}, len(schema.columnNames))
+		_ = jsoniter.ConfigFastest.Unmarshal([]byte(value), &decoded)
+		convertDataFromJSON(schema.fields, 0, decoded)
+		return decoded
+	}
+	return decodeRedisCodecValue(registry, value)
+}
+
+func decodeRedisCodecValue(registry *validatedRegistry, value string) []interface{} {
+	codec, has := registry.redisCodecs[value[0]]
+	if !has || len(value) < 2 {
+		panic(fmt.Errorf("unknown redis codec %d", value[0]))
+	}
+	data := []byte(value[2:])
+	if value[1]&redisCodecFlagCompressed > 0 {
+		reader := flate.NewReader(bytes.NewReader(data))
+		decompressed, err := ioutil.ReadAll(reader)
+		_ = reader.Close()
+		checkError(err)
+		data = decompressed
+	}
+	row, err := codec.Decode(data)
+	checkError(err)
+	return row
+}
+
+func encodeCachedPage(config *redisCodecConfig, total int, ids []uint64) interface{} {
+	if config == nil {
+		if len(ids) == 0 {
+			return total
+		}
+		values := []uint64{uint64(total)}
+		values = append(values, ids...)
+		return strings.Trim(fmt.Sprintf("%v", values), "[]")
+	}
+	row := make([]interface{}, len(ids)+1)
+	row[0] = uint64(total)
+	for i, id := range ids {
+		row[i+1] = id
+	}
+	return encodeRedisRow(config, row)
+}
+
+func decodeCachedPage(registry *validatedRegistry, value string) (total int, ids []uint64) {
+	if value == "" || value[0] > redisCodecMaxID {
+		parts := strings.Split(value, " ")
+		total, _ = strconv.Atoi(parts[0])
+		ids = make([]uint64, len(parts)-1)
+		for i := 1; i < len(parts); i++ {
+			ids[i-1], _ = strconv.ParseUint(parts[i], 10, 64)
+		}
+		return total, ids
+	}
+	row := decodeRedisCodecValue(registry, value)
+	total = int(row[0].(uint64))
+	ids = make([]uint64, len(row)-1)
+	for i := 1; i < len(row); i++ {
+		ids[i-1] = row[i].(uint64)
+	}
+	return total, ids
+}
+
+func (r *Registry) getRedisCodec(name string) (RedisCodec, bool) {
+	if name == "binary" {
+		return &binaryRedisCodec{}, true
+	}
+	codec, has := r.redisCodecs[name]
+	return codec, has
+}
+
+func (r *Registry) validateRedisCodecs(registry *validatedRegistry) error {
+	binaryCodec := &binaryRedisCodec{}
+	registry.redisCodecs = map[byte]RedisCodec{binaryCodec.ID(): binaryCodec}
+	for name, codec := range r.redisCodecs {
+		if name == "binary" || name == "json" {
+			return fmt.Errorf("redis codec name '%s' is reserved", name)
+		}
+		if codec.ID() < 2 || codec.ID() > redisCodecMaxID {
+			return fmt.Errorf("redis codec '%s' has invalid ID %d", name, codec.ID())
+		}
+		duplicated, has := registry.redisCodecs[codec.ID()]
+		if has {
+			return fmt.Errorf("redis codec '%s' has the same ID as '%s'", name, duplicated.Name())
+		}
+		registry.redisCodecs[codec.ID()] = codec
+	}
+	for pool, setting := range r.redisCodecPools {
+		_, has := registry.redisServers[pool]
+		if !has {
+			return fmt.Errorf("redis pool '%s' not found", pool)
+		}
+		if setting.codec == "json" {
+			continue
+		}
+		_, has = r.getRedisCodec(setting.codec)
+		if !has {
+			return fmt.Errorf("unknown redis codec '%s'", setting.codec)
+		}
+	}
+	return nil
+}
+
+func parseRedisCodec(registry *Registry, tags map[string]map[string]string, pool string, entityType reflect.Type) (*redisCodecConfig, error) {
+	setting := redisCodecSetting{codec: "json"}
+	if pool != "" && registry.redisCodecPools[pool] != nil {
+		setting = *registry.redisCodecPools[pool]
+	}
+	for _, tag := range []string{"redisCodec", "redisCompressAbove"} {
+		userValue, has := tags["ORM"][tag]
+		if !has {
+			continue
+		}
+		if pool == "" {
+			return nil, fmt.Errorf("%s defined in %s without cache pool", tag, entityType.String())
+		}
+		if tag == "redisCodec" {
+			setting.codec = userValue
+			continue
+		}
+		compressAbove, err := strconv.Atoi(userValue)
+		if err != nil || compressAbove <= 0 {
+			return nil, fmt.Errorf("invalid %s '%s' in %s", tag, userValue, entityType.String())
+		}
+		setting.compressAbove = compressAbove
+	}
+	if setting.codec == "json" {
+		if setting.compressAbove > 0 {
+			return nil, fmt.Errorf("redisCompressAbove in %s requires redisCodec", entityType.String())
+		}
+		return nil, nil
+	}
+	codec, has := registry.getRedisCodec(setting.codec)
+	if !has {
+		return nil, fmt.Errorf("unknown redis codec '%s' in %s", setting.codec, entityType.String())
+	}
+	return &redisCodecConfig{codec: codec, compressAbove: setting.compressAbove}, nil
+}
@@ -0,0 +1,150 @@
+package orm
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type redisCodecEntity struct {
+	ORM         `orm:"redisCache;redisCodec=binary;redisCompressAbove=20"`
+	ID          uint
+	Name        string `orm:"unique=name"`
+	Age         int
+	Nullable    *uint
+	Enabled     bool
+	Balance     float64
+	IndexAll    *CachedQuery `query:""`
+	IndexByName *CachedQuery `query:":Name = ?"`
+}
+
+type testRedisCodec struct {
+	id byte
+}
+
+func (c *testRedisCodec) ID() byte {
+	return c.id
+}
+
+func (c *testRedisCodec) Name() string {
+	return "test"
+}
+
+func (c *testRedisCodec) Encode(row []interface{}) []byte {
+	return (&binaryRedisCodec{}).Encode(row)
+}
+
+func (c *testRedisCodec) Decode(data []byte) ([]interface{}, error) {
+	return (&binaryRedisCodec{}).Decode(data)
+}
+
+func TestRedisCodecEncode(t *testing.T) {
+	registry := &validatedRegistry{redisCodecs: map[byte]RedisCodec{1: &binaryRedisCodec{}}}
+	row := []interface{}{uint64(7), int64(-3), "name", nil, true, false, 1.25}
+	config := &redisCodecConfig{codec: &binaryRedisCodec{}}
+	encoded := encodeRedisRow(config, row)
+	assert.Equal(t, byte(1), encoded[0])
+	assert.Equal(t, byte(0), encoded[1])
+	assert.Equal(t, row, decodeRedisCodecValue(registry, encoded))
+
+	row[2] = strings.Repeat("a", 1000)
+	config.compressAbove = 100
+	encoded = encodeRedisRow(config, row)
+	assert.Equal(t, byte(redisCodecFlagCompressed), encoded[1])
+	assert.Less(t, len(encoded), 100)
+	assert.Equal(t, row, decodeRedisCodecValue(registry, encoded))
+
+	assert.Equal(t, `[7,-3]`, encodeRedisRow(nil, []interface{}{uint64(7), int64(-3)}))
+	assert.PanicsWithError(t, "unknown redis codec 9", func() {
+		decodeRedisCodecValue(registry, string([]byte{9, 0}))
+	})
+	_, err := (&binaryRedisCodec{}).Decode([]byte{3, 10, 'a'})
+	assert.EqualError(t, err, "invalid binary redis value")
+
+	total, ids := decodeCachedPage(registry, "12 3 4")
+	assert.Equal(t, 12, total)
+	assert.Equal(t, []uint64{3, 4}, ids)
+	total, ids = decodeCachedPage(registry, "5")
+	assert.Equal(t, 5, total)
+	assert.Len(t, ids, 0)
+	assert.Equal(t, "12 3 4", encodeCachedPage(nil, 12, []uint64{3, 4}))
+	assert.Equal(t, 5, encodeCachedPage(nil, 5, nil))
+	total, ids = decodeCachedPage(registry, encodeCachedPage(config, 12, []uint64{3, 4}).(string))
+	assert.Equal(t, 12, total)
+	assert.Equal(t, []uint64{3, 4}, ids)
+}
+
+func TestRedisCodecRegistry(t *testing.T) {
+	registry := &Registry{}
+	registry.RegisterRedis("localhost:6381", 15)
+	registry.RegisterRedisCodec(&testRedisCodec{id: 1})
+	_, err := registry.Validate()
+	assert.EqualError(t, err, "redis codec 'test' has invalid ID 1")
+
+	registry = &Registry{}
+	registry.RegisterRedis("localhost:6381", 15)
+	registry.RegisterMySQLPool("root:root@tcp(localhost:3311)/test")
+	registry.RegisterRedisCodec(&testRedisCodec{id: 2})
+	registry.SetRedisCodec("test", 0)
+	registry.RegisterEntity(&redisCodecEntity{})
+	validatedRegistry, err := registry.Validate()
+	assert.Nil(t, err)
+	schema := validatedRegistry.GetTableSchemaForEntity(&redisCodecEntity{}).(*tableSchema)
+	assert.Equal(t, "binary", schema.redisCodec.codec.Name())
+	assert.Equal(t, 20, schema.redisCodec.compressAbove)
+
+	registry = &Registry{}
+	registry.SetRedisCodec("missing", 0, "other")
+	_, err = registry.Validate()
+	assert.EqualError(t, err, "redis pool 'other' not found")
+}
+
+func TestRedisCodecLoad(t *testing.T) {
+	var entity *redisCodecEntity
+	engine := PrepareTables(t, &Registry{}, 5, entity)
+	schema := engine.GetRegistry().GetTableSchemaForEntity(entity).(*tableSchema)
+
+	nullable := uint(3)
+	engine.FlushMany(&redisCodecEntity{Name: "a", Age: -10, Nullable: &nullable, Enabled: true, Balance: 12.5},
+		&redisCodecEntity{Name: strings.Repeat("b", 100)})
+
+	legacy := `[1,3,-10,"a",true,12.5]`
+	engine.GetRedis().Set(schema.getCacheKey(1), legacy, 30)
+	entity = &redisCodecEntity{}
+	assert.True(t, engine.LoadByID(1, entity))
+	assert.Equal(t, "a", entity.Name)
+	assert.Equal(t, -10, entity.Age)
+	assert.Equal(t, uint(3), *entity.Nullable)
+
+	engine.GetRedis().FlushDB()
+	entity = &redisCodecEntity{}
+	assert.True(t, engine.LoadByID(2, entity))
+	value, has := engine.GetRedis().Get(schema.getCacheKey(2))
+	assert.True(t, has)
+	assert.Equal(t, byte(1), value[0])
+	assert.Equal(t, byte(redisCodecFlagCompressed), value[1])
+
+	entity = &redisCodecEntity{}
+	assert.True(t, engine.LoadByID(2, entity))
+	assert.Equal(t, strings.Repeat("b", 100), entity.Name)
+	assert.Nil(t, entity.Nullable)
+
+	var rows []*redisCodecEntity
+	engine.LoadByIDs([]uint64{1, 2}, &rows)
+	assert.Len(t, rows, 2)
+	assert.Equal(t, 12.5, rows[0].Balance)
+	assert.True(t, rows[0].Enabled)
+
+	engine.GetRedis().FlushDB()
+	totalRows := engine.CachedSearch(&rows, "IndexAll", nil)
+	assert.Equal(t, 2, totalRows)
+	totalRows = engine.CachedSearch(&rows, "IndexAll", nil)
+	assert.Equal(t, 2, totalRows)
+	assert.Equal(t, uint(1), rows[0].ID)
+	assert.Equal(t, uint(2), rows[1].ID)
+	totalRows = engine.CachedSearch(&rows, "IndexByName", nil, "c")
+	assert.Equal(t, 0, totalRows)
+	totalRows = engine.CachedSearch(&rows, "IndexByName", nil, "c")
+	assert.Equal(t, 0, totalRows)
+}
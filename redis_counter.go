@@ -0,0 +1,97 @@
+package orm
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+const redisWindowedCounterIncrScript = `redis.call('INCRBY', KEYS[1], ARGV[1])
+redis.call('EXPIRE', KEYS[1], ARGV[2])
+return 1`
+
+const redisUniqueCounterAddScript = `return redis.call('PFADD', KEYS[1], unpack(ARGV))`
+
+type RedisWindowedCounter struct {
+	redis   *RedisCache
+	name    string
+	bucket  time.Duration
+	buckets int
+	now     func() time.Time
+}
+
+type RedisUniqueCounter struct {
+	redis *RedisCache
+	name  string
+}
+
+func (r *RedisCache) WindowedCounter(name string, bucket time.Duration, buckets int) *RedisWindowedCounter {
+	if bucket < time.Second || buckets <= 0 {
+		panic(fmt.Errorf("invalid windowed counter %s definition", name))
+	}
+	return &RedisWindowedCounter{redis: r, name: name, bucket: bucket, buckets: buckets, now: time.Now}
+}
+
+func (c *RedisWindowedCounter) Incr(value int64) {
+	key, ttl := c.bucketKey(), c.ttl()
+	pipeLine := c.redis.PipeLine()
+	pipeLine.IncrBy(key, value)
+	pipeLine.Expire(key, ttl)
+	pipeLine.Exec()
+}
+
+func (c *RedisWindowedCounter) IncrInFlusher(flusher RedisFlusher, value int64) {
+	flusher.eval(c.redis.code, redisWindowedCounterIncrScript, []string{c.bucketKey()}, value, int64(c.ttl()/time.Second))
+}
+
+func (c *RedisWindowedCounter) Buckets() []int64 {
+	keys := make([]string, c.buckets)
+	current := c.now().Truncate(c.bucket)
+	for i := 0; i < c.buckets; i++ {
+		keys[i] = c.key(current.Add(-c.bucket * time.Duration(c.buckets-1-i)))
+	}
+	values := c.redis.MGet(keys...)
+	buckets := make([]int64, c.buckets)
+	for i, key := range keys {
+		if values[key] != nil {
+			buckets[i], _ = strconv.ParseInt(values[key].(string), 10, 64)
+		}
+	}
+	return buckets
+}
+
+func (c *RedisWindowedCounter) Sum() int64 {
+	sum := int64(0)
+	for _, value := range c.Buckets() {
+		sum += value
+	}
+	return sum
+}
+
+func (c *RedisWindowedCounter) bucketKey() string {
+	return c.key(c.now().Truncate(c.bucket))
+}
+
+func (c *RedisWindowedCounter) key(bucket time.Time) string {
+	return c.name + ":" + strconv.FormatInt(bucket.Unix(), 10)
+}
+
+func (c *RedisWindowedCounter) ttl() time.Duration {
+	return c.bucket * time.Duration(c.buckets+1)
+}
+
+func (r *RedisCache) UniqueCounter(name string) *RedisUniqueCounter {
+	return &RedisUniqueCounter{redis: r, name: name}
+}
+
+func (c *RedisUniqueCounter) Add(members ...interface{}) (changed bool) {
+	return c.redis.PFAdd(c.name, members...)
+}
+
+func (c *RedisUniqueCounter) AddInFlusher(flusher RedisFlusher, members ...interface{}) {
+	flusher.eval(c.redis.code, redisUniqueCounterAddScript, []string{c.name}, members...)
+}
+
+func (c *RedisUniqueCounter) Count() int64 {
+	return c.redis.PFCount(c.name)
+}
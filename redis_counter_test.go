@@ -0,0 +1,65 @@
+package orm
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedisWindowedCounter(t *testing.T) {
+	registry := &Registry{}
+	registry.RegisterRedis("localhost:6381", 15)
+	validatedRegistry, err := registry.Validate()
+	assert.Nil(t, err)
+	engine := validatedRegistry.CreateEngine()
+	r := engine.GetRedis()
+	r.FlushDB()
+
+	now := time.Unix(1600000000, 0)
+	counter := r.WindowedCounter("visits", time.Minute, 3)
+	counter.now = func() time.Time {
+		return now
+	}
+	counter.Incr(2)
+	counter.Incr(3)
+	assert.Equal(t, []int64{0, 0, 5}, counter.Buckets())
+	now = now.Add(time.Minute)
+	counter.Incr(1)
+	flusher := engine.NewRedisFlusher()
+	counter.IncrInFlusher(flusher, 4)
+	assert.Equal(t, int64(6), counter.Sum())
+	flusher.Flush()
+	assert.Equal(t, []int64{0, 5, 5}, counter.Buckets())
+	assert.Equal(t, int64(10), counter.Sum())
+	_, has, ttl := r.getWithTTL("visits:" + "1600000020")
+	assert.True(t, has)
+	assert.True(t, ttl > time.Minute*3 && ttl <= time.Minute*4)
+
+	now = now.Add(time.Minute * 2)
+	assert.Equal(t, []int64{5, 0, 0}, counter.Buckets())
+	assert.Equal(t, int64(5), counter.Sum())
+
+	assert.PanicsWithError(t, "invalid windowed counter visits definition", func() {
+		r.WindowedCounter("visits", time.Millisecond, 3)
+	})
+}
+
+func TestRedisUniqueCounter(t *testing.T) {
+	registry := &Registry{}
+	registry.RegisterRedis("localhost:6381", 15)
+	validatedRegistry, err := registry.Validate()
+	assert.Nil(t, err)
+	engine := validatedRegistry.CreateEngine()
+	r := engine.GetRedis()
+	r.FlushDB()
+
+	counter := r.UniqueCounter("users")
+	assert.True(t, counter.Add("a", "b", "c"))
+	assert.False(t, counter.Add("a"))
+	flusher := engine.NewRedisFlusher()
+	counter.AddInFlusher(flusher, "c", "d")
+	assert.Equal(t, int64(3), counter.Count())
+	flusher.Flush()
+	assert.Equal(t, int64(4), counter.Count())
+}
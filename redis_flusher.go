@@ -1,6 +1,7 @@
 package orm
 
 import (
+	"strconv"
 	"sync"
 
 	jsoniter "github.com/json-iterator/go"
@@ -10,6 +11,9 @@ const (
 	commandDelete = iota
 	commandXAdd   = iota
 	commandHSet   = iota
+	commandIncr   = iota
+	commandBits   = iota
+	commandEval   = iota
 )
 
 type RedisFlusher interface {
@@ -18,6 +22,9 @@ type RedisFlusher interface {
 	Publish(stream string, event interface{})
 	Flush()
 	HSet(redisPool, key string, values ...interface{})
+	incrementIfExists(redisPool, key string, delta float64)
+	setBloomFilterBits(redisPool, key string, offsets ...uint64)
+	eval(redisPool, script string, keys []string, args ...interface{})
 }
 
 type redisFlusherCommands struct {
@@ -26,6 +33,15 @@ type redisFlusherCommands struct {
 	deletes []string
 	hSets   map[string][]interface{}
 	events  map[string][]EventAsMap
+	incrs   map[string]float64
+	bits    map[string][]interface{}
+	evals   []*redisFlusherEval
+}
+
+type redisFlusherEval struct {
+	script string
+	keys   []string
+	args   []interface{}
 }
 
 type redisFlusher struct {
@@ -108,6 +124,80 @@ func (f *redisFlusher) HSet(redisPool, key string, values ...interface{}) {
 	commands.hSets[key] = values
 }
 
+func (f *redisFlusher) incrementIfExists(redisPool, key string, delta float64) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if f.pipelines == nil {
+		f.pipelines = make(map[string]*redisFlusherCommands)
+	}
+	commands, has := f.pipelines[redisPool]
+	if !has {
+		commands = &redisFlusherCommands{incrs: map[string]float64{key: delta}, diffs: map[int]bool{commandIncr: true}}
+		f.pipelines[redisPool] = commands
+		return
+	}
+	commands.diffs[commandIncr] = true
+	if commands.incrs == nil {
+		commands.incrs = make(map[string]float64)
+	}
+	commands.incrs[key] += delta
+}
+
+func (f *redisFlusher) setBloomFilterBits(redisPool, key string, offsets ...uint64) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if f.pipelines == nil {
+		f.pipelines = make(map[string]*redisFlusherCommands)
+	}
+	commands, has := f.pipelines[redisPool]
+	if !has {
+		commands = &redisFlusherCommands{diffs: map[int]bool{commandBits: true}}
+		f.pipelines[redisPool] = commands
+	}
+	commands.diffs[commandBits] = true
+	if commands.bits == nil {
+		commands.bits = make(map[string][]interface{})
+	}
+	for _, offset := range offsets {
+		commands.bits[key] = append(commands.bits[key], offset)
+	}
+}
+
+func (f *redisFlusher) eval(redisPool, script string, keys []string, args ...interface{}) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if f.pipelines == nil {
+		f.pipelines = make(map[string]*redisFlusherCommands)
+	}
+	commands, has := f.pipelines[redisPool]
+	if !has {
+		commands = &redisFlusherCommands{diffs: map[int]bool{commandEval: true}}
+		f.pipelines[redisPool] = commands
+	}
+	commands.diffs[commandEval] = true
+	commands.evals = append(commands.evals, &redisFlusherEval{script: script, keys: keys, args: args})
+	commands.usePool = commands.usePool || len(commands.evals) > 1
+}
+
+func (commands *redisFlusherCommands) incrementArguments(cluster bool) (keys [][]string, deltas [][]interface{}) {
+	all := make([]string, 0, len(commands.incrs))
+	for key := range commands.incrs {
+		all = append(all, key)
+	}
+	groups := groupRedisKeysBySlot(cluster, all)
+	keys = make([][]string, len(groups))
+	deltas = make([][]interface{}, len(groups))
+	for i, group := range groups {
+		keys[i] = make([]string, 0, len(group)*2)
+		deltas[i] = make([]interface{}, len(group))
+		for j, key := range group {
+			keys[i] = append(keys[i], key, key+cachedAggregatePendingSuffix)
+			deltas[i][j] = strconv.FormatFloat(commands.incrs[key], 'f', -1, 64)
+		}
+	}
+	return keys, deltas
+}
+
 func (f *redisFlusher) Flush() {
 	f.mutex.Lock()
 	defer f.mutex.Unlock()
@@ -121,6 +211,18 @@ func (f *redisFlusher) Flush() {
 			for key, values := range commands.hSets {
 				p.HSet(key, values...)
 			}
+			if commands.incrs != nil {
+				keys, deltas := commands.incrementArguments(p.cluster)
+				for i, group := range keys {
+					p.Eval(cachedAggregateIncrementScript, group, deltas[i]...)
+				}
+			}
+			for key, offsets := range commands.bits {
+				p.Eval(bloomFilterAddScript, []string{key, key + bloomFilterBuildingSuffix}, offsets...)
+			}
+			for _, eval := range commands.evals {
+				p.Eval(eval.script, eval.keys, eval.args...)
+			}
 			for stream, events := range commands.events {
 				for _, event := range events {
 					var v map[string]interface{} = event
@@ -138,6 +240,18 @@ func (f *redisFlusher) Flush() {
 					r.HSet(key, values...)
 				}
 			}
+			if commands.incrs != nil {
+				keys, deltas := commands.incrementArguments(r.cluster)
+				for i, group := range keys {
+					r.Eval(cachedAggregateIncrementScript, group, deltas[i]...)
+				}
+			}
+			for key, offsets := range commands.bits {
+				r.Eval(bloomFilterAddScript, []string{key, key + bloomFilterBuildingSuffix}, offsets...)
+			}
+			for _, eval := range commands.evals {
+				r.Eval(eval.script, eval.keys, eval.args...)
+			}
 			for stream, events := range commands.events {
 				for _, event := range events {
 					var v map[string]interface{} = event
@@ -0,0 +1,71 @@
+package orm
+
+import (
+	"github.com/go-redis/redis/v8"
+)
+
+const redisLeaderboardIncrScript = `return redis.call('ZINCRBY', KEYS[1], ARGV[1], ARGV[2])`
+
+type RedisLeaderboard struct {
+	redis *RedisCache
+	name  string
+}
+
+type RedisLeaderboardRank struct {
+	Member string
+	Rank   int64
+	Score  float64
+}
+
+func (r *RedisCache) Leaderboard(name string) *RedisLeaderboard {
+	return &RedisLeaderboard{redis: r, name: name}
+}
+
+func (l *RedisLeaderboard) Set(member string, score float64) {
+	l.redis.ZAdd(l.name, &redis.Z{Score: score, Member: member})
+}
+
+func (l *RedisLeaderboard) Incr(member string, delta float64) float64 {
+	return l.redis.ZIncrBy(l.name, delta, member)
+}
+
+func (l *RedisLeaderboard) IncrInFlusher(flusher RedisFlusher, member string, delta float64) {
+	flusher.eval(l.redis.code, redisLeaderboardIncrScript, []string{l.name}, delta, member)
+}
+
+func (l *RedisLeaderboard) Remove(members ...string) {
+	values := make([]interface{}, len(members))
+	for i, member := range members {
+		values[i] = member
+	}
+	l.redis.ZRem(l.name, values...)
+}
+
+func (l *RedisLeaderboard) Count() int64 {
+	return l.redis.ZCard(l.name)
+}
+
+func (l *RedisLeaderboard) Rank(member string) (rank *RedisLeaderboardRank, has bool) {
+	pipeLine := l.redis.PipeLine()
+	rankCmd := pipeLine.ZRevRank(l.name, member)
+	scoreCmd := pipeLine.ZScore(l.name, member)
+	pipeLine.Exec()
+	position, err := rankCmd.Result()
+	if err == redis.Nil {
+		return nil, false
+	}
+	checkError(err)
+	score, err := scoreCmd.Result()
+	checkError(err)
+	return &RedisLeaderboardRank{Member: member, Rank: position + 1, Score: score}, true
+}
+
+func (l *RedisLeaderboard) Page(pager *Pager) []*RedisLeaderboardRank {
+	start := int64((pager.CurrentPage - 1) * pager.PageSize)
+	rows := l.redis.ZRevRangeWithScores(l.name, start, start+int64(pager.PageSize)-1)
+	ranks := make([]*RedisLeaderboardRank, len(rows))
+	for i, row := range rows {
+		ranks[i] = &RedisLeaderboardRank{Member: row.Member.(string), Rank: start + int64(i) + 1, Score: row.Score}
+	}
+	return ranks
+}
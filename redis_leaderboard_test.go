@@ -0,0 +1,44 @@
+package orm
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedisLeaderboard(t *testing.T) {
+	registry := &Registry{}
+	registry.RegisterRedis("localhost:6381", 15)
+	validatedRegistry, err := registry.Validate()
+	assert.Nil(t, err)
+	engine := validatedRegistry.CreateEngine()
+	r := engine.GetRedis()
+	r.FlushDB()
+
+	leaderboard := r.Leaderboard("ranking")
+	leaderboard.Set("a", 10)
+	leaderboard.Set("b", 20)
+	assert.Equal(t, 15.0, leaderboard.Incr("c", 15))
+	flusher := engine.NewRedisFlusher()
+	leaderboard.IncrInFlusher(flusher, "a", 15)
+	flusher.Flush()
+	assert.Equal(t, int64(3), leaderboard.Count())
+
+	rank, has := leaderboard.Rank("a")
+	assert.True(t, has)
+	assert.Equal(t, &RedisLeaderboardRank{Member: "a", Rank: 1, Score: 25}, rank)
+	rank, has = leaderboard.Rank("missing")
+	assert.False(t, has)
+	assert.Nil(t, rank)
+
+	page := leaderboard.Page(NewPager(1, 2))
+	assert.Len(t, page, 2)
+	assert.Equal(t, "a", page[0].Member)
+	assert.Equal(t, "b", page[1].Member)
+	page = leaderboard.Page(NewPager(2, 2))
+	assert.Len(t, page, 1)
+	assert.Equal(t, &RedisLeaderboardRank{Member: "c", Rank: 3, Score: 15}, page[0])
+
+	leaderboard.Remove("c")
+	assert.Equal(t, int64(2), leaderboard.Count())
+}
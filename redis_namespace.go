@@ -0,0 +1,93 @@
+package orm
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+const redisNamespaceScanCount = 1000
+
+var redisGlobEscaper = strings.NewReplacer(`\`, `\\`, "*", `\*`, "?", `\?`, "[", `\[`, "]", `\]`)
+
+func (r *RedisCache) prefixKey(key string) string {
+	return r.prefix + key
+}
+
+func (r *RedisCache) prefixPairs(pairs []interface{}) []interface{} {
+	if r.prefix == "" {
+		return pairs
+	}
+	prefixed := make([]interface{}, len(pairs))
+	for i := 0; i < len(pairs); i += 2 {
+		prefixed[i] = r.prefix + pairs[i].(string)
+		prefixed[i+1] = pairs[i+1]
+	}
+	return prefixed
+}
+
+func redisPrefixKeys(prefix string, keys []string) []string {
+	if prefix == "" {
+		return keys
+	}
+	prefixed := make([]string, len(keys))
+	for i, key := range keys {
+		prefixed[i] = prefix + key
+	}
+	return prefixed
+}
+
+func (r *RedisCache) FlushNamespace() (deleted int64) {
+	if r.prefix == "" {
+		panic(fmt.Errorf("redis pool '%s' has no prefix", r.code))
+	}
+	start := time.Now()
+	var err error
+	if r.cluster {
+		err = r.client.(*redis.ClusterClient).ForEachMaster(r.ctx, func(ctx context.Context, client *redis.Client) error {
+			total, flushErr := flushRedisNamespace(ctx, client, r.prefix)
+			atomic.AddInt64(&deleted, total)
+			return flushErr
+		})
+	} else {
+		deleted, err = flushRedisNamespace(r.ctx, r.client, r.prefix)
+	}
+	if r.engine.hasRedisLogger {
+		r.fillLogFields("[ORM][REDIS][FLUSHNAMESPACE]", start, "flushnamespace", -1, int(deleted),
+			map[string]interface{}{"prefix": r.prefix}, err)
+	}
+	checkError(err)
+	return deleted
+}
+
+func flushRedisNamespace(ctx context.Context, client redis.Cmdable, prefix string) (deleted int64, err error) {
+	match := redisGlobEscaper.Replace(prefix) + "*"
+	cursor := uint64(0)
+	for {
+		var keys []string
+		keys, cursor, err = client.Scan(ctx, cursor, match, redisNamespaceScanCount).Result()
+		if err != nil {
+			return deleted, err
+		}
+		if len(keys) > 0 {
+			pipeline := client.Pipeline()
+			for _, key := range keys {
+				pipeline.Del(ctx, key)
+			}
+			cmds, err := pipeline.Exec(ctx)
+			if err != nil {
+				return deleted, err
+			}
+			for _, cmd := range cmds {
+				deleted += cmd.(*redis.IntCmd).Val()
+			}
+		}
+		if cursor == 0 {
+			return deleted, nil
+		}
+	}
+}
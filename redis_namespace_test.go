@@ -0,0 +1,73 @@
+package orm
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedisNamespace(t *testing.T) {
+	registry := &Registry{}
+	registry.RegisterRedis("localhost:6381", 15)
+	registry.RegisterRedis("localhost:6381", 15, "app")
+	registry.SetRedisPrefix("app:", "app")
+	registry.RegisterLocker("app", "app")
+	registry.RegisterRedisStream("app-stream", "app", []string{"app-group"})
+	validatedRegistry, err := registry.Validate()
+	assert.Nil(t, err)
+	engine := validatedRegistry.CreateEngine()
+	r := engine.GetRedis()
+	app := engine.GetRedis("app")
+	r.FlushDB()
+
+	app.Set("a", "1", 10)
+	app.MSet("b", "2", "c", "3")
+	app.HSet("hash", "f", "v")
+	r.Set("a", "other", 10)
+	value, has := app.Get("a")
+	assert.True(t, has)
+	assert.Equal(t, "1", value)
+	value, _ = r.Get("app:a")
+	assert.Equal(t, "1", value)
+	value, _ = r.Get("a")
+	assert.Equal(t, "other", value)
+	assert.Equal(t, map[string]interface{}{"b": "2", "c": "3", "d": nil}, app.MGet("b", "c", "d"))
+	assert.Equal(t, int64(1), app.Eval("return redis.call('EXISTS', KEYS[1])", []string{"hash"}))
+
+	pipeLine := app.PipeLine()
+	get := pipeLine.Get("b")
+	pipeLine.Exec()
+	value, has, _ = get.Result()
+	assert.True(t, has)
+	assert.Equal(t, "2", value)
+
+	app.xAdd("app-stream", map[string]interface{}{"a": "b"})
+	assert.Equal(t, int64(1), r.XLen("app:app-stream"))
+	app.XGroupCreate("app-stream", "app-group", "0")
+	streams := app.XReadGroup(&redis.XReadGroupArgs{Group: "app-group", Consumer: "c", Streams: []string{"app-stream", ">"},
+		Count: 10, Block: time.Millisecond * 100})
+	assert.Len(t, streams, 1)
+	assert.Equal(t, "app-stream", streams[0].Stream)
+
+	lock, has := engine.GetLocker("app").Obtain(context.Background(), "lock", time.Second, 0)
+	assert.True(t, has)
+	assert.Equal(t, int64(1), r.Exists("app:lock"))
+	lock.Release()
+
+	assert.Equal(t, int64(5), app.FlushNamespace())
+	assert.Equal(t, int64(0), r.Exists("app:a", "app:b", "app:c", "app:hash", "app:app-stream"))
+	value, has = r.Get("a")
+	assert.True(t, has)
+	assert.Equal(t, "other", value)
+	assert.PanicsWithError(t, "redis pool 'default' has no prefix", func() {
+		r.FlushNamespace()
+	})
+
+	registry = &Registry{}
+	registry.SetRedisPrefix("app:", "missing")
+	_, err = registry.Validate()
+	assert.EqualError(t, err, "redis pool 'missing' not found")
+}
@@ -18,46 +18,221 @@ type RedisPipeLine struct {
 	executed     bool
 	commands     int
 	xaddCommands int
+	cluster      bool
+	prefix       string
+	transaction  bool
+	watch        *RedisTransaction
 }
 
 func (rp *RedisPipeLine) Del(key ...string) *PipeLineInt {
 	rp.commands++
-	return &PipeLineInt{p: rp, cmd: rp.pipeLine.Del(rp.ctx, key...)}
+	if !rp.cluster {
+		return &PipeLineInt{p: rp, cmd: rp.pipeLine.Del(rp.ctx, redisPrefixKeys(rp.prefix, key)...)}
+	}
+	result := &PipeLineInt{p: rp}
+	for _, group := range groupRedisKeysBySlot(true, redisPrefixKeys(rp.prefix, key)) {
+		result.cmds = append(result.cmds, rp.pipeLine.Del(rp.ctx, group...))
+	}
+	return result
 }
 
 func (rp *RedisPipeLine) Get(key string) *PipeLineGet {
 	rp.commands++
-	return &PipeLineGet{p: rp, cmd: rp.pipeLine.Get(rp.ctx, key)}
+	return &PipeLineGet{p: rp, cmd: rp.pipeLine.Get(rp.ctx, rp.prefix+key)}
 }
 
 func (rp *RedisPipeLine) Set(key string, value interface{}, expiration time.Duration) *PipeLineStatus {
 	rp.commands++
-	return &PipeLineStatus{p: rp, cmd: rp.pipeLine.Set(rp.ctx, key, value, expiration)}
+	return &PipeLineStatus{p: rp, cmd: rp.pipeLine.Set(rp.ctx, rp.prefix+key, value, expiration)}
 }
 
 func (rp *RedisPipeLine) Expire(key string, expiration time.Duration) *PipeLineBool {
 	rp.commands++
-	return &PipeLineBool{p: rp, cmd: rp.pipeLine.Expire(rp.ctx, key, expiration)}
+	return &PipeLineBool{p: rp, cmd: rp.pipeLine.Expire(rp.ctx, rp.prefix+key, expiration)}
 }
 
 func (rp *RedisPipeLine) HIncrBy(key, field string, incr int64) *PipeLineInt {
 	rp.commands++
-	return &PipeLineInt{p: rp, cmd: rp.pipeLine.HIncrBy(rp.ctx, key, field, incr)}
+	return &PipeLineInt{p: rp, cmd: rp.pipeLine.HIncrBy(rp.ctx, rp.prefix+key, field, incr)}
 }
 
 func (rp *RedisPipeLine) HSet(key string, values ...interface{}) *PipeLineInt {
 	rp.commands++
-	return &PipeLineInt{p: rp, cmd: rp.pipeLine.HSet(rp.ctx, key, values...)}
+	return &PipeLineInt{p: rp, cmd: rp.pipeLine.HSet(rp.ctx, rp.prefix+key, values...)}
 }
 
 func (rp *RedisPipeLine) HDel(key string, values ...string) *PipeLineInt {
 	rp.commands++
-	return &PipeLineInt{p: rp, cmd: rp.pipeLine.HDel(rp.ctx, key, values...)}
+	return &PipeLineInt{p: rp, cmd: rp.pipeLine.HDel(rp.ctx, rp.prefix+key, values...)}
+}
+
+func (rp *RedisPipeLine) MGet(keys ...string) *PipeLineMap {
+	rp.commands++
+	result := &PipeLineMap{p: rp}
+	for _, group := range groupRedisKeysBySlot(rp.cluster, redisPrefixKeys(rp.prefix, keys)) {
+		result.cmds = append(result.cmds, rp.pipeLine.MGet(rp.ctx, group...))
+		names := make([]string, len(group))
+		for i, key := range group {
+			names[i] = key[len(rp.prefix):]
+		}
+		result.keys = append(result.keys, names)
+	}
+	return result
+}
+
+func (rp *RedisPipeLine) Incr(key string) *PipeLineInt {
+	rp.commands++
+	return &PipeLineInt{p: rp, cmd: rp.pipeLine.Incr(rp.ctx, rp.prefix+key)}
+}
+
+func (rp *RedisPipeLine) IncrBy(key string, incr int64) *PipeLineInt {
+	rp.commands++
+	return &PipeLineInt{p: rp, cmd: rp.pipeLine.IncrBy(rp.ctx, rp.prefix+key, incr)}
+}
+
+func (rp *RedisPipeLine) HGet(key, field string) *PipeLineGet {
+	rp.commands++
+	return &PipeLineGet{p: rp, cmd: rp.pipeLine.HGet(rp.ctx, rp.prefix+key, field)}
+}
+
+func (rp *RedisPipeLine) HGetAll(key string) *PipeLineStringMap {
+	rp.commands++
+	return &PipeLineStringMap{p: rp, cmd: rp.pipeLine.HGetAll(rp.ctx, rp.prefix+key)}
+}
+
+func (rp *RedisPipeLine) HMget(key string, fields ...string) *PipeLineMap {
+	rp.commands++
+	return &PipeLineMap{p: rp, keys: [][]string{fields}, cmds: []*redis.SliceCmd{rp.pipeLine.HMGet(rp.ctx, rp.prefix+key, fields...)}}
+}
+
+func (rp *RedisPipeLine) HLen(key string) *PipeLineInt {
+	rp.commands++
+	return &PipeLineInt{p: rp, cmd: rp.pipeLine.HLen(rp.ctx, rp.prefix+key)}
+}
+
+func (rp *RedisPipeLine) SAdd(key string, members ...interface{}) *PipeLineInt {
+	rp.commands++
+	return &PipeLineInt{p: rp, cmd: rp.pipeLine.SAdd(rp.ctx, rp.prefix+key, members...)}
+}
+
+func (rp *RedisPipeLine) SCard(key string) *PipeLineInt {
+	rp.commands++
+	return &PipeLineInt{p: rp, cmd: rp.pipeLine.SCard(rp.ctx, rp.prefix+key)}
+}
+
+func (rp *RedisPipeLine) SPop(key string) *PipeLineGet {
+	rp.commands++
+	return &PipeLineGet{p: rp, cmd: rp.pipeLine.SPop(rp.ctx, rp.prefix+key)}
+}
+
+func (rp *RedisPipeLine) SPopN(key string, max int64) *PipeLineStringSlice {
+	rp.commands++
+	return &PipeLineStringSlice{p: rp, cmd: rp.pipeLine.SPopN(rp.ctx, rp.prefix+key, max)}
+}
+
+func (rp *RedisPipeLine) ZAdd(key string, members ...*redis.Z) *PipeLineInt {
+	rp.commands++
+	return &PipeLineInt{p: rp, cmd: rp.pipeLine.ZAdd(rp.ctx, rp.prefix+key, members...)}
+}
+
+func (rp *RedisPipeLine) ZRevRange(key string, start, stop int64) *PipeLineStringSlice {
+	rp.commands++
+	return &PipeLineStringSlice{p: rp, cmd: rp.pipeLine.ZRevRange(rp.ctx, rp.prefix+key, start, stop)}
+}
+
+func (rp *RedisPipeLine) ZRevRangeWithScores(key string, start, stop int64) *PipeLineZSlice {
+	rp.commands++
+	return &PipeLineZSlice{p: rp, cmd: rp.pipeLine.ZRevRangeWithScores(rp.ctx, rp.prefix+key, start, stop)}
+}
+
+func (rp *RedisPipeLine) ZRangeWithScores(key string, start, stop int64) *PipeLineZSlice {
+	rp.commands++
+	return &PipeLineZSlice{p: rp, cmd: rp.pipeLine.ZRangeWithScores(rp.ctx, rp.prefix+key, start, stop)}
+}
+
+func (rp *RedisPipeLine) ZCard(key string) *PipeLineInt {
+	rp.commands++
+	return &PipeLineInt{p: rp, cmd: rp.pipeLine.ZCard(rp.ctx, rp.prefix+key)}
+}
+
+func (rp *RedisPipeLine) ZCount(key string, min, max string) *PipeLineInt {
+	rp.commands++
+	return &PipeLineInt{p: rp, cmd: rp.pipeLine.ZCount(rp.ctx, rp.prefix+key, min, max)}
+}
+
+func (rp *RedisPipeLine) ZScore(key, member string) *PipeLineFloat {
+	rp.commands++
+	return &PipeLineFloat{p: rp, cmd: rp.pipeLine.ZScore(rp.ctx, rp.prefix+key, member)}
+}
+
+func (rp *RedisPipeLine) ZIncrBy(key string, increment float64, member string) *PipeLineFloat {
+	rp.commands++
+	return &PipeLineFloat{p: rp, cmd: rp.pipeLine.ZIncrBy(rp.ctx, rp.prefix+key, increment, member)}
+}
+
+func (rp *RedisPipeLine) ZRevRank(key, member string) *PipeLineInt {
+	rp.commands++
+	return &PipeLineInt{p: rp, cmd: rp.pipeLine.ZRevRank(rp.ctx, rp.prefix+key, member)}
+}
+
+func (rp *RedisPipeLine) PFAdd(key string, members ...interface{}) *PipeLineInt {
+	rp.commands++
+	return &PipeLineInt{p: rp, cmd: rp.pipeLine.PFAdd(rp.ctx, rp.prefix+key, members...)}
+}
+
+func (rp *RedisPipeLine) PFCount(key string) *PipeLineInt {
+	rp.commands++
+	return &PipeLineInt{p: rp, cmd: rp.pipeLine.PFCount(rp.ctx, rp.prefix+key)}
+}
+
+func (rp *RedisPipeLine) LPush(key string, values ...interface{}) *PipeLineInt {
+	rp.commands++
+	return &PipeLineInt{p: rp, cmd: rp.pipeLine.LPush(rp.ctx, rp.prefix+key, values...)}
+}
+
+func (rp *RedisPipeLine) RPush(key string, values ...interface{}) *PipeLineInt {
+	rp.commands++
+	return &PipeLineInt{p: rp, cmd: rp.pipeLine.RPush(rp.ctx, rp.prefix+key, values...)}
+}
+
+func (rp *RedisPipeLine) LLen(key string) *PipeLineInt {
+	rp.commands++
+	return &PipeLineInt{p: rp, cmd: rp.pipeLine.LLen(rp.ctx, rp.prefix+key)}
+}
+
+func (rp *RedisPipeLine) LRange(key string, start, stop int64) *PipeLineStringSlice {
+	rp.commands++
+	return &PipeLineStringSlice{p: rp, cmd: rp.pipeLine.LRange(rp.ctx, rp.prefix+key, start, stop)}
+}
+
+func (rp *RedisPipeLine) LSet(key string, index int64, value interface{}) *PipeLineStatus {
+	rp.commands++
+	return &PipeLineStatus{p: rp, cmd: rp.pipeLine.LSet(rp.ctx, rp.prefix+key, index, value)}
+}
+
+func (rp *RedisPipeLine) RPop(key string) *PipeLineGet {
+	rp.commands++
+	return &PipeLineGet{p: rp, cmd: rp.pipeLine.RPop(rp.ctx, rp.prefix+key)}
+}
+
+func (rp *RedisPipeLine) LRem(key string, count int64, value interface{}) *PipeLineInt {
+	rp.commands++
+	return &PipeLineInt{p: rp, cmd: rp.pipeLine.LRem(rp.ctx, rp.prefix+key, count, value)}
+}
+
+func (rp *RedisPipeLine) Ltrim(key string, start, stop int64) *PipeLineStatus {
+	rp.commands++
+	return &PipeLineStatus{p: rp, cmd: rp.pipeLine.LTrim(rp.ctx, rp.prefix+key, start, stop)}
+}
+
+func (rp *RedisPipeLine) Eval(script string, keys []string, args ...interface{}) *PipeLineCmd {
+	rp.commands++
+	return &PipeLineCmd{p: rp, cmd: rp.pipeLine.Eval(rp.ctx, script, redisPrefixKeys(rp.prefix, keys), args...)}
 }
 
 func (rp *RedisPipeLine) XAdd(stream string, values interface{}) *PipeLineString {
 	rp.xaddCommands++
-	return &PipeLineString{p: rp, cmd: rp.pipeLine.XAdd(rp.ctx, &redis.XAddArgs{Stream: stream, Values: values})}
+	return &PipeLineString{p: rp, cmd: rp.pipeLine.XAdd(rp.ctx, &redis.XAddArgs{Stream: rp.prefix + redisStreamKey(rp.cluster, stream), Values: values})}
 }
 
 func (rp *RedisPipeLine) Exec() {
@@ -70,6 +245,10 @@ func (rp *RedisPipeLine) Exec() {
 	if err != nil && err == redis.Nil {
 		err = nil
 	}
+	if err == redis.TxFailedErr && rp.watch != nil {
+		rp.watch.failed = true
+		err = nil
+	}
 	if rp.engine.hasRedisLogger {
 		rp.fillLogFields(start, err)
 	}
@@ -105,15 +284,88 @@ func (c *PipeLineString) Result() (string, error) {
 }
 
 type PipeLineInt struct {
-	p   *RedisPipeLine
-	cmd *redis.IntCmd
+	p    *RedisPipeLine
+	cmd  *redis.IntCmd
+	cmds []*redis.IntCmd
 }
 
 func (c *PipeLineInt) Result() (int64, error) {
+	checkExecuted(c.p)
+	if c.cmd != nil {
+		return c.cmd.Result()
+	}
+	total := int64(0)
+	for _, cmd := range c.cmds {
+		val, err := cmd.Result()
+		if err != nil {
+			return total, err
+		}
+		total += val
+	}
+	return total, nil
+}
+
+type PipeLineFloat struct {
+	p   *RedisPipeLine
+	cmd *redis.FloatCmd
+}
+
+func (c *PipeLineFloat) Result() (float64, error) {
+	checkExecuted(c.p)
+	return c.cmd.Result()
+}
+
+type PipeLineStringSlice struct {
+	p   *RedisPipeLine
+	cmd *redis.StringSliceCmd
+}
+
+func (c *PipeLineStringSlice) Result() ([]string, error) {
 	checkExecuted(c.p)
 	return c.cmd.Result()
 }
 
+type PipeLineStringMap struct {
+	p   *RedisPipeLine
+	cmd *redis.StringStringMapCmd
+}
+
+func (c *PipeLineStringMap) Result() (map[string]string, error) {
+	checkExecuted(c.p)
+	return c.cmd.Result()
+}
+
+type PipeLineZSlice struct {
+	p   *RedisPipeLine
+	cmd *redis.ZSliceCmd
+}
+
+func (c *PipeLineZSlice) Result() ([]redis.Z, error) {
+	checkExecuted(c.p)
+	return c.cmd.Result()
+}
+
+type PipeLineMap struct {
+	p    *RedisPipeLine
+	keys [][]string
+	cmds []*redis.SliceCmd
+}
+
+func (c *PipeLineMap) Result() (map[string]interface{}, error) {
+	checkExecuted(c.p)
+	results := make(map[string]interface{})
+	for i, cmd := range c.cmds {
+		val, err := cmd.Result()
+		if err != nil {
+			return results, err
+		}
+		for index, v := range val {
+			results[c.keys[i][index]] = v
+		}
+	}
+	return results, nil
+}
+
 type PipeLineBool struct {
 	p   *RedisPipeLine
 	cmd *redis.BoolCmd
@@ -124,6 +376,16 @@ func (c *PipeLineBool) Result() (bool, error) {
 	return c.cmd.Result()
 }
 
+type PipeLineCmd struct {
+	p   *RedisPipeLine
+	cmd *redis.Cmd
+}
+
+func (c *PipeLineCmd) Result() (interface{}, error) {
+	checkExecuted(c.p)
+	return c.cmd.Result()
+}
+
 type PipeLineStatus struct {
 	p   *RedisPipeLine
 	cmd *redis.StatusCmd
@@ -158,11 +420,16 @@ func (rp *RedisPipeLine) fillLogFields(start time.Time, err error) {
 	}
 	if rp.engine.hasRedisLogger && rp.commands > 0 {
 		message := "[ORM][REDIS][EXEC]"
+		operation := "exec"
+		if rp.transaction {
+			message = "[ORM][REDIS][MULTI]"
+			operation = "multi"
+		}
 		now := time.Now()
 		stop := time.Since(start).Microseconds()
 		e := rp.engine.queryLoggers[QueryLoggerSourceRedis].log.WithFields(log2.Fields{
 			"microseconds": stop,
-			"operation":    "exec",
+			"operation":    operation,
 			"commands":     rp.commands,
 			"pool":         rp.pool,
 			"target":       "redis",
@@ -74,3 +74,105 @@ func TestRedisPipeline(t *testing.T) {
 	assert.Len(t, events, 1)
 	assert.Len(t, events[0].Messages, 3)
 }
+
+func TestRedisPipelineCommands(t *testing.T) {
+	registry := &Registry{}
+	registry.RegisterRedis("localhost:6381", 15)
+	validatedRegistry, err := registry.Validate()
+	assert.Nil(t, err)
+	engine := validatedRegistry.CreateEngine()
+	r := engine.GetRedis()
+	r.FlushDB()
+	r.Set("a", "A", 10)
+	r.HSet("hash", "f1", "v1", "f2", "v2")
+
+	pipeLine := r.PipeLine()
+	mGet := pipeLine.MGet("a", "b")
+	hGet := pipeLine.HGet("hash", "f1")
+	hGetAll := pipeLine.HGetAll("hash")
+	hMget := pipeLine.HMget("hash", "f2", "f3")
+	pipeLine.SAdd("set", "a", "b")
+	sCard := pipeLine.SCard("set")
+	pipeLine.ZAdd("zset", &redis.Z{Score: 1, Member: "a"}, &redis.Z{Score: 2, Member: "b"})
+	zRevRange := pipeLine.ZRevRange("zset", 0, 1)
+	zScore := pipeLine.ZScore("zset", "b")
+	zRange := pipeLine.ZRangeWithScores("zset", 0, 0)
+	pipeLine.RPush("list", "a", "b", "c")
+	lRange := pipeLine.LRange("list", 0, -1)
+	rPop := pipeLine.RPop("list")
+	incr := pipeLine.IncrBy("counter", 5)
+	pipeLine.Exec()
+
+	values, err := mGet.Result()
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"a": "A", "b": nil}, values)
+	value, has, _ := hGet.Result()
+	assert.True(t, has)
+	assert.Equal(t, "v1", value)
+	all, _ := hGetAll.Result()
+	assert.Equal(t, map[string]string{"f1": "v1", "f2": "v2"}, all)
+	values, _ = hMget.Result()
+	assert.Equal(t, map[string]interface{}{"f2": "v2", "f3": nil}, values)
+	count, _ := sCard.Result()
+	assert.Equal(t, int64(2), count)
+	members, _ := zRevRange.Result()
+	assert.Equal(t, []string{"b", "a"}, members)
+	score, _ := zScore.Result()
+	assert.Equal(t, 2.0, score)
+	z, _ := zRange.Result()
+	assert.Equal(t, []redis.Z{{Score: 1, Member: "a"}}, z)
+	list, _ := lRange.Result()
+	assert.Equal(t, []string{"a", "b", "c"}, list)
+	value, has, _ = rPop.Result()
+	assert.True(t, has)
+	assert.Equal(t, "c", value)
+	count, _ = incr.Result()
+	assert.Equal(t, int64(5), count)
+}
+
+func TestRedisTransaction(t *testing.T) {
+	registry := &Registry{}
+	registry.RegisterRedis("localhost:6381", 15)
+	validatedRegistry, err := registry.Validate()
+	assert.Nil(t, err)
+	engine := validatedRegistry.CreateEngine()
+	r := engine.GetRedis()
+	r.FlushDB()
+	testLogger := memory.New()
+	engine.AddQueryLogger(testLogger, apexLog.InfoLevel, QueryLoggerSourceRedis)
+
+	txPipeLine := r.TxPipeLine()
+	c1 := txPipeLine.Incr("counter")
+	c2 := txPipeLine.Incr("counter")
+	txPipeLine.Exec()
+	val, _ := c1.Result()
+	assert.Equal(t, int64(1), val)
+	val, _ = c2.Result()
+	assert.Equal(t, int64(2), val)
+	assert.Len(t, testLogger.Entries, 1)
+	assert.Equal(t, "[ORM][REDIS][MULTI]", testLogger.Entries[0].Message)
+
+	r.Set("balance", "100", 0)
+	committed := r.Watch(func(tx *RedisTransaction) {
+		value, has := tx.Get("balance")
+		assert.True(t, has)
+		assert.Equal(t, "100", value)
+		txPipeLine := tx.TxPipeLine()
+		txPipeLine.Set("balance", "90", 0)
+		txPipeLine.Exec()
+	}, "balance")
+	assert.True(t, committed)
+	value, _ := r.Get("balance")
+	assert.Equal(t, "90", value)
+
+	committed = r.Watch(func(tx *RedisTransaction) {
+		tx.Get("balance")
+		r.Set("balance", "50", 0)
+		txPipeLine := tx.TxPipeLine()
+		txPipeLine.Set("balance", "80", 0)
+		txPipeLine.Exec()
+	}, "balance")
+	assert.False(t, committed)
+	value, _ = r.Get("balance")
+	assert.Equal(t, "50", value)
+}
@@ -0,0 +1,80 @@
+package orm
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+const redisSubscribeReconnectDelay = time.Second
+
+type RedisMessageHandler func(message *redis.Message)
+
+func (r *RedisCache) Publish(channel string, message interface{}) (receivers int64) {
+	start := time.Now()
+	receivers, err := r.client.Publish(r.ctx, r.prefixKey(channel), message).Result()
+	if r.engine.hasRedisLogger {
+		r.fillLogFields("[ORM][REDIS][PUBLISH]", start, "publish", -1, 1,
+			map[string]interface{}{"Channel": channel, "receivers": receivers}, err)
+	}
+	checkError(err)
+	return receivers
+}
+
+func (r *RedisCache) Subscribe(ctx context.Context, handler RedisMessageHandler, channels ...string) {
+	r.subscribe(ctx, handler, false, channels)
+}
+
+func (r *RedisCache) PSubscribe(ctx context.Context, handler RedisMessageHandler, patterns ...string) {
+	r.subscribe(ctx, handler, true, patterns)
+}
+
+func (r *RedisCache) subscribe(ctx context.Context, handler RedisMessageHandler, pattern bool, channels []string) {
+	message := "[ORM][REDIS][SUBSCRIBE]"
+	operation := "subscribe"
+	var pubSub *redis.PubSub
+	if pattern {
+		message = "[ORM][REDIS][PSUBSCRIBE]"
+		operation = "psubscribe"
+		pubSub = r.client.PSubscribe(ctx, redisPrefixKeys(r.prefix, channels)...)
+	} else {
+		pubSub = r.client.Subscribe(ctx, redisPrefixKeys(r.prefix, channels)...)
+	}
+	defer func() {
+		_ = pubSub.Close()
+	}()
+	start := time.Now()
+	for {
+		received, err := pubSub.Receive(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			if r.engine.hasRedisLogger {
+				r.fillLogFields(message, start, operation, -1, len(channels),
+					map[string]interface{}{"Channels": channels}, err)
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(redisSubscribeReconnectDelay):
+			}
+			start = time.Now()
+			continue
+		}
+		switch m := received.(type) {
+		case *redis.Subscription:
+			if r.engine.hasRedisLogger {
+				r.fillLogFields(message, start, operation, -1, 1,
+					map[string]interface{}{"Channel": m.Channel, "kind": m.Kind}, nil)
+			}
+		case *redis.Message:
+			m.Channel = m.Channel[len(r.prefix):]
+			if m.Pattern != "" {
+				m.Pattern = m.Pattern[len(r.prefix):]
+			}
+			handler(m)
+		}
+	}
+}
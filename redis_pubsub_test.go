@@ -0,0 +1,69 @@
+package orm
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	apexLog "github.com/apex/log"
+	"github.com/apex/log/handlers/memory"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedisPubSub(t *testing.T) {
+	registry := &Registry{}
+	registry.RegisterRedis("localhost:6381", 15)
+	validatedRegistry, err := registry.Validate()
+	assert.Nil(t, err)
+	engine := validatedRegistry.CreateEngine()
+	testLogger := memory.New()
+	engine.AddQueryLogger(testLogger, apexLog.InfoLevel, QueryLoggerSourceRedis)
+	r := engine.GetRedis()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	messages := make(chan *redis.Message, 10)
+	done := make(chan struct{})
+	go func() {
+		r.Subscribe(ctx, func(message *redis.Message) {
+			messages <- message
+		}, "test-channel")
+		close(done)
+	}()
+	patternDone := make(chan struct{})
+	go func() {
+		r.PSubscribe(ctx, func(message *redis.Message) {
+			messages <- message
+		}, "test-*")
+		close(patternDone)
+	}()
+	time.Sleep(time.Millisecond * 200)
+
+	assert.Equal(t, int64(2), r.Publish("test-channel", "hello"))
+	for i := 0; i < 2; i++ {
+		select {
+		case message := <-messages:
+			assert.Equal(t, "test-channel", message.Channel)
+			assert.Equal(t, "hello", message.Payload)
+		case <-time.After(time.Second):
+			assert.Fail(t, "message not received")
+		}
+	}
+	assert.Equal(t, int64(1), r.Publish("test-other", "world"))
+	select {
+	case message := <-messages:
+		assert.Equal(t, "test-*", message.Pattern)
+		assert.Equal(t, "world", message.Payload)
+	case <-time.After(time.Second):
+		assert.Fail(t, "message not received")
+	}
+
+	cancel()
+	<-done
+	<-patternDone
+	found := false
+	for _, entry := range testLogger.Entries {
+		found = found || entry.Message == "[ORM][REDIS][PSUBSCRIBE]"
+	}
+	assert.True(t, found)
+}
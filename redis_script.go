@@ -0,0 +1,120 @@
+package orm
+
+import (
+	"crypto/sha1"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+const redisGarbageCollectorScript = "orm-garbage-collector"
+
+var internalRedisScripts = map[string]string{
+	redisGarbageCollectorScript: `
+local count = 0
+local all = 0
+while(true)
+do
+	local T = redis.call('XRANGE', KEYS[1], "-", ARGV[1], "COUNT", 1000)
+	local ids = {}
+	for _, v in pairs(T) do
+		table.insert(ids, v[1])
+		count = count + 1
+	end
+	if table.getn(ids) > 0 then
+		redis.call('XDEL', KEYS[1], unpack(ids))
+	end
+	if table.getn(ids) < 1000 then
+		all = 1
+		break
+	end
+	if count >= 100000 then
+		break
+	end
+end
+return all
+`,
+}
+
+type redisScript struct {
+	source string
+	sha1   string
+}
+
+type redisScripts struct {
+	scripts map[string]*redisScript
+	loaded  map[string]bool
+	mutex   sync.Mutex
+}
+
+func (r *Registry) RegisterScript(name string, source string) {
+	if r.redisScripts == nil {
+		r.redisScripts = make(map[string]string)
+	}
+	r.redisScripts[name] = source
+}
+
+func (r *Registry) validateRedisScripts(registry *validatedRegistry) error {
+	registry.redisScripts = &redisScripts{scripts: make(map[string]*redisScript), loaded: make(map[string]bool)}
+	for name, source := range internalRedisScripts {
+		registry.redisScripts.add(name, source)
+	}
+	for name, source := range r.redisScripts {
+		if name == "" {
+			return fmt.Errorf("redis script name is empty")
+		}
+		_, has := internalRedisScripts[name]
+		if has {
+			return fmt.Errorf("redis script name '%s' is reserved", name)
+		}
+		if strings.TrimSpace(source) == "" {
+			return fmt.Errorf("redis script '%s' is empty", name)
+		}
+		registry.redisScripts.add(name, source)
+	}
+	return nil
+}
+
+func (s *redisScripts) add(name, source string) {
+	s.scripts[name] = &redisScript{source: source, sha1: fmt.Sprintf("%x", sha1.Sum([]byte(source)))}
+}
+
+func (s *redisScripts) preload(r *RedisCache) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.loaded[r.code] {
+		return
+	}
+	for _, script := range s.scripts {
+		r.ScriptLoad(script.source)
+	}
+	s.loaded[r.code] = true
+}
+
+func (r *RedisCache) RunScript(name string, keys []string, args ...interface{}) interface{} {
+	scripts := r.engine.registry.redisScripts
+	script, has := scripts.scripts[name]
+	if !has {
+		panic(fmt.Errorf("unregistered redis script '%s'", name))
+	}
+	scripts.preload(r)
+	start := time.Now()
+	prefixed := redisPrefixKeys(r.prefix, keys)
+	res, err := r.client.EvalSha(r.ctx, script.sha1, prefixed, args...).Result()
+	noScript := err != nil && strings.HasPrefix(err.Error(), "NOSCRIPT")
+	if noScript {
+		res, err = r.client.Eval(r.ctx, script.source, prefixed, args...).Result()
+	}
+	if err == redis.Nil {
+		err = nil
+	}
+	if r.engine.hasRedisLogger {
+		r.fillLogFields("[ORM][REDIS][SCRIPT]", start, "script", -1, len(keys),
+			map[string]interface{}{"script": name, "Keys": keys, "noscript": noScript}, err)
+	}
+	checkError(err)
+	return res
+}
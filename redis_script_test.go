@@ -0,0 +1,50 @@
+package orm
+
+import (
+	"testing"
+
+	apexLog "github.com/apex/log"
+	"github.com/apex/log/handlers/memory"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedisScript(t *testing.T) {
+	registry := &Registry{}
+	registry.RegisterRedis("localhost:6381", 15)
+	registry.RegisterScript("incr-by", "return redis.call('INCRBY', KEYS[1], ARGV[1])")
+	registry.RegisterScript("empty-result", "return nil")
+	validatedRegistry, err := registry.Validate()
+	assert.Nil(t, err)
+	engine := validatedRegistry.CreateEngine()
+	r := engine.GetRedis()
+	r.FlushDB()
+	testLogger := memory.New()
+	engine.AddQueryLogger(testLogger, apexLog.InfoLevel, QueryLoggerSourceRedis)
+
+	assert.Equal(t, int64(5), r.RunScript("incr-by", []string{"counter"}, 5))
+	assert.Equal(t, int64(7), r.RunScript("incr-by", []string{"counter"}, 2))
+	assert.Nil(t, r.RunScript("empty-result", nil))
+	last := testLogger.Entries[len(testLogger.Entries)-1]
+	assert.Equal(t, "[ORM][REDIS][SCRIPT]", last.Message)
+	assert.Equal(t, "empty-result", last.Fields["script"])
+	assert.Equal(t, false, last.Fields["noscript"])
+
+	r.client.ScriptFlush(r.ctx)
+	assert.Equal(t, int64(8), r.RunScript("incr-by", []string{"counter"}, 1))
+	last = testLogger.Entries[len(testLogger.Entries)-1]
+	assert.Equal(t, true, last.Fields["noscript"])
+
+	assert.PanicsWithError(t, "unregistered redis script 'missing'", func() {
+		r.RunScript("missing", nil)
+	})
+
+	registry = &Registry{}
+	registry.RegisterScript(redisGarbageCollectorScript, "return 1")
+	_, err = registry.Validate()
+	assert.EqualError(t, err, "redis script name 'orm-garbage-collector' is reserved")
+
+	registry = &Registry{}
+	registry.RegisterScript("test", " ")
+	_, err = registry.Validate()
+	assert.EqualError(t, err, "redis script 'test' is empty")
+}
@@ -488,7 +488,7 @@ func (r *RedisSearch) Search(index string, query *RedisSearchQuery, pager *Pager
 		if i > max {
 			break
 		}
-		row := &RedisSearchResult{Key: data[i].(string)}
+		row := &RedisSearchResult{Key: data[i].(string)[len(r.redis.prefix):]}
 		if query.explainScore {
 			i++
 			row.ExplainScore = data[i].([]interface{})
@@ -517,13 +517,13 @@ func (r *RedisSearch) SearchKeys(index string, query *RedisSearchQuery, pager *P
 	total, rows := r.search(index, query, pager, true)
 	keys = make([]string, len(rows))
 	for k, v := range rows {
-		keys[k] = v.(string)
+		keys[k] = v.(string)[len(r.redis.prefix):]
 	}
 	return total, keys
 }
 
 func (r *RedisSearch) search(index string, query *RedisSearchQuery, pager *Pager, noContent bool) (total uint64, rows []interface{}) {
-	args := []interface{}{"FT.SEARCH", index}
+	args := []interface{}{"FT.SEARCH", r.redis.prefixKey(index)}
 	q := query.query
 	for field, in := range query.filtersNumeric {
 		if len(in) == 1 {
@@ -641,24 +641,26 @@ func (r *RedisSearch) search(index string, query *RedisSearchQuery, pager *Pager
 		args = append(args, (pager.CurrentPage-1)*pager.PageSize)
 		args = append(args, pager.PageSize)
 	}
-	cmd := redis.NewSliceCmd(r.ctx, args...)
-	start := time.Now()
-	err := r.redis.client.Process(r.ctx, cmd)
-	if r.engine.hasRedisLogger {
-		r.fillLogFields("[ORM][REDIS-SEARCH][FT.SEARCH]", start, "ft_search", 1,
-			map[string]interface{}{"Index": index, "args": args[2:]}, err)
-	}
-	checkError(err)
-	res, err := cmd.Result()
-	checkError(err)
-	total = uint64(res[0].(int64))
-	return total, res[1:]
+	result := requestCacheMemo(r.engine, getRequestCacheSearchGroup(r.code, index), fmt.Sprintf("%v", args), func() interface{} {
+		cmd := redis.NewSliceCmd(r.ctx, args...)
+		start := time.Now()
+		err := r.redis.client.Process(r.ctx, cmd)
+		if r.engine.hasRedisLogger {
+			r.fillLogFields("[ORM][REDIS-SEARCH][FT.SEARCH]", start, "ft_search", 1,
+				map[string]interface{}{"Index": index, "args": args[2:]}, err)
+		}
+		checkError(err)
+		res, err := cmd.Result()
+		checkError(err)
+		return &requestCacheSearchResult{total: uint64(res[0].(int64)), rows: res[1:]}
+	}).(*requestCacheSearchResult)
+	return result.total, copyRequestCacheRows(result.rows)
 }
 
 func (r *RedisSearch) createIndexArgs(index *RedisSearchIndex, indexName string) []interface{} {
-	args := []interface{}{"FT.CREATE", indexName, "ON", "HASH", "PREFIX", len(index.Prefixes)}
+	args := []interface{}{"FT.CREATE", r.redis.prefixKey(indexName), "ON", "HASH", "PREFIX", len(index.Prefixes)}
 	for _, prefix := range index.Prefixes {
-		args = append(args, prefix)
+		args = append(args, r.redis.prefixKey(prefix))
 	}
 	if index.Filter != "" {
 		args = append(args, "FILTER", index.Filter)
@@ -732,7 +734,7 @@ func (r *RedisSearch) createIndexArgs(index *RedisSearchIndex, indexName string)
 }
 
 func (r *RedisSearch) aliasUpdate(name, index string) {
-	cmd := redis.NewStringCmd(r.ctx, "FT.ALIASUPDATE", name, index)
+	cmd := redis.NewStringCmd(r.ctx, "FT.ALIASUPDATE", r.redis.prefixKey(name), r.redis.prefixKey(index))
 	err := r.redis.client.Process(r.ctx, cmd)
 	checkError(err)
 }
@@ -761,11 +763,20 @@ func (r *RedisSearch) ListIndices() []string {
 	checkError(err)
 	res, err := cmd.Result()
 	checkError(err)
-	return res
+	if r.redis.prefix == "" {
+		return res
+	}
+	indices := make([]string, 0, len(res))
+	for _, name := range res {
+		if strings.HasPrefix(name, r.redis.prefix) {
+			indices = append(indices, name[len(r.redis.prefix):])
+		}
+	}
+	return indices
 }
 
 func (r *RedisSearch) dropIndex(indexName string, withHashes bool) string {
-	args := []interface{}{"FT.DROPINDEX", indexName}
+	args := []interface{}{"FT.DROPINDEX", r.redis.prefixKey(indexName)}
 	if withHashes {
 		args = append(args, "DD")
 	}
@@ -783,7 +794,7 @@ func (r *RedisSearch) dropIndex(indexName string, withHashes bool) string {
 }
 
 func (r *RedisSearch) Info(indexName string) *RedisSearchIndexInfo {
-	cmd := redis.NewSliceCmd(r.ctx, "FT.INFO", indexName)
+	cmd := redis.NewSliceCmd(r.ctx, "FT.INFO", r.redis.prefixKey(indexName))
 	start := time.Now()
 	err := r.redis.client.Process(r.ctx, cmd)
 	has := true
@@ -805,7 +816,7 @@ func (r *RedisSearch) Info(indexName string) *RedisSearchIndexInfo {
 	for i, row := range res {
 		switch row {
 		case "index_name":
-			info.Name = res[i+1].(string)
+			info.Name = strings.TrimPrefix(res[i+1].(string), r.redis.prefix)
 		case "index_options":
 			infoOptions := res[i+1].([]interface{})
 			options := RedisSearchIndexInfoOptions{}
@@ -966,6 +977,9 @@ func getRedisSearchAlters(engine *Engine) (alters []RedisSearchIndexAlter) {
 		groupedList := make(map[string][]int64)
 		for _, name := range search.ListIndices() {
 			parts := strings.Split(name, ":")
+			if len(parts) > 2 {
+				continue
+			}
 			id := int64(0)
 			if len(parts) == 2 {
 				id, _ = strconv.ParseInt(parts[1], 10, 64)
@@ -981,7 +995,7 @@ func getRedisSearchAlters(engine *Engine) (alters []RedisSearchIndexAlter) {
 			if !has {
 				for _, id := range groupedList[name] {
 					indexName := name + ":" + strconv.FormatInt(id, 10)
-					query := "FT.DROPINDEX " + indexName
+					query := "FT.DROPINDEX " + search.redis.prefixKey(indexName)
 					alter := RedisSearchIndexAlter{Pool: poolName, Query: query, search: search}
 					nameToRemove := indexName
 					alter.Execute = func() {
@@ -1006,6 +1020,7 @@ func getRedisSearchAlters(engine *Engine) (alters []RedisSearchIndexAlter) {
 			if len(prefixes) == 0 || (len(prefixes) == 1 && prefixes[0] == "") {
 				prefixes = []string{""}
 			}
+			prefixes = redisPrefixKeys(search.redis.prefix, prefixes)
 			if !reflect.DeepEqual(info.Definition.Prefixes, prefixes) {
 				changes = append(changes, "different prefixes")
 			}
@@ -454,3 +454,41 @@ func TestRedisSearch(t *testing.T) {
 	assert.Len(t, alters[0].Changes, 1)
 	assert.Equal(t, "unneeded field text_field", alters[0].Changes[0])
 }
+
+func TestRedisSearchNamespace(t *testing.T) {
+	registry := &Registry{}
+	registry.RegisterRedis("localhost:6383", 0, "app")
+	registry.SetRedisPrefix("app:", "app")
+	index := &RedisSearchIndex{Name: "test", RedisPool: "search", Prefixes: []string{"doc:"}}
+	index.AddTextField("title", 1, true, false, false)
+	registry.RegisterRedisSearchIndex(index)
+	appIndex := &RedisSearchIndex{Name: "test", RedisPool: "app", Prefixes: []string{"doc:"}}
+	appIndex.AddTextField("title", 1, true, false, false)
+	registry.RegisterRedisSearchIndex(appIndex)
+	engine := PrepareTables(t, registry, 5)
+
+	assert.Len(t, engine.GetRedisSearchIndexAlters(), 0)
+	search := engine.GetRedisSearch("search")
+	app := engine.GetRedisSearch("app")
+	all := search.ListIndices()
+	assert.Len(t, all, 2)
+	appIndices := app.ListIndices()
+	assert.Len(t, appIndices, 1)
+	assert.True(t, strings.HasPrefix(appIndices[0], "test:"))
+	assert.Contains(t, all, "app:"+appIndices[0])
+	info := app.Info("test")
+	assert.Equal(t, appIndices[0], info.Name)
+	assert.Equal(t, []string{"app:doc:"}, info.Definition.Prefixes)
+
+	engine.GetRedis("app").HSet("doc:1", "title", "hello")
+	engine.GetRedis("search").HSet("doc:2", "title", "hello")
+	total, keys := app.SearchKeys("test", (&RedisSearchQuery{}).Query("hello"), NewPager(1, 10))
+	assert.Equal(t, uint64(1), total)
+	assert.Equal(t, []string{"doc:1"}, keys)
+
+	app.dropIndex(appIndices[0], false)
+	assert.Len(t, search.ListIndices(), 1)
+	alters := engine.GetRedisSearchIndexAlters()
+	assert.Len(t, alters, 1)
+	assert.Equal(t, "app", alters[0].Pool)
+}
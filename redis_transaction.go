@@ -0,0 +1,82 @@
+package orm
+
+import (
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+type RedisTransaction struct {
+	cache  *RedisCache
+	tx     *redis.Tx
+	failed bool
+}
+
+func (r *RedisCache) TxPipeLine() *RedisPipeLine {
+	return &RedisPipeLine{ctx: r.client.Context(), pool: r.code, engine: r.engine, pipeLine: r.client.TxPipeline(),
+		cluster: r.cluster, prefix: r.prefix, transaction: true}
+}
+
+func (r *RedisCache) Watch(handler func(tx *RedisTransaction), keys ...string) (committed bool) {
+	start := time.Now()
+	transaction := &RedisTransaction{cache: r}
+	err := r.client.Watch(r.ctx, func(tx *redis.Tx) error {
+		transaction.tx = tx
+		handler(transaction)
+		return nil
+	}, redisPrefixKeys(r.prefix, keys)...)
+	if r.engine.hasRedisLogger {
+		r.fillLogFields("[ORM][REDIS][WATCH]", start, "watch", -1, len(keys),
+			map[string]interface{}{"Keys": keys, "committed": !transaction.failed}, err)
+	}
+	checkError(err)
+	return !transaction.failed
+}
+
+func (t *RedisTransaction) TxPipeLine() *RedisPipeLine {
+	return &RedisPipeLine{ctx: t.tx.Context(), pool: t.cache.code, engine: t.cache.engine, pipeLine: t.tx.TxPipeline(),
+		cluster: t.cache.cluster, prefix: t.cache.prefix, transaction: true, watch: t}
+}
+
+func (t *RedisTransaction) Get(key string) (value string, has bool) {
+	start := time.Now()
+	val, err := t.tx.Get(t.cache.ctx, t.cache.prefixKey(key)).Result()
+	if err == redis.Nil {
+		err = nil
+	} else {
+		has = true
+	}
+	if t.cache.engine.hasRedisLogger {
+		t.cache.fillLogFields("[ORM][REDIS][GET]", start, "get", boolToInt(!has), 1,
+			map[string]interface{}{"Key": key}, err)
+	}
+	checkError(err)
+	return val, has
+}
+
+func (t *RedisTransaction) HGetAll(key string) map[string]string {
+	start := time.Now()
+	val, err := t.tx.HGetAll(t.cache.ctx, t.cache.prefixKey(key)).Result()
+	if t.cache.engine.hasRedisLogger {
+		t.cache.fillLogFields("[ORM][REDIS][HGETALL]", start, "hgetall", -1, 1,
+			map[string]interface{}{"Key": key}, err)
+	}
+	checkError(err)
+	return val
+}
+
+func (t *RedisTransaction) ZScore(key, member string) (score float64, has bool) {
+	start := time.Now()
+	val, err := t.tx.ZScore(t.cache.ctx, t.cache.prefixKey(key), member).Result()
+	if err == redis.Nil {
+		err = nil
+	} else {
+		has = true
+	}
+	if t.cache.engine.hasRedisLogger {
+		t.cache.fillLogFields("[ORM][REDIS][ZSCORE]", start, "zscore", boolToInt(!has), 1,
+			map[string]interface{}{"Key": key, "member": member}, err)
+	}
+	checkError(err)
+	return val, has
+}
@@ -1,6 +1,7 @@
 package orm
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	log2 "log"
@@ -16,25 +17,30 @@ import (
 
 	"github.com/go-redis/redis/v8"
 	_ "github.com/go-sql-driver/mysql" // force this mysql driver
-	"github.com/golang/groupcache/lru"
 	"github.com/jmoiron/sqlx"
 	"github.com/olivere/elastic/v7"
 )
 
 type Registry struct {
-	sqlClients           map[string]*DBConfig
-	clickHouseClients    map[string]*ClickHouseConfig
-	localCacheContainers map[string]*LocalCacheConfig
-	redisServers         map[string]*RedisCacheConfig
-	elasticServers       map[string]*ElasticConfig
-	entities             map[string]reflect.Type
-	redisSearchIndices   map[string]map[string]*RedisSearchIndex
-	elasticIndices       map[string]map[string]ElasticIndexDefinition
-	enums                map[string]Enum
-	locks                map[string]string
-	defaultEncoding      string
-	redisStreamGroups    map[string]map[string]map[string]bool
-	redisStreamPools     map[string]string
+	sqlClients                 map[string]*DBConfig
+	clickHouseClients          map[string]*ClickHouseConfig
+	localCacheContainers       map[string]*LocalCacheConfig
+	redisServers               map[string]*RedisCacheConfig
+	elasticServers             map[string]*ElasticConfig
+	entities                   map[string]reflect.Type
+	redisSearchIndices         map[string]map[string]*RedisSearchIndex
+	elasticIndices             map[string]map[string]ElasticIndexDefinition
+	enums                      map[string]Enum
+	locks                      map[string]string
+	defaultEncoding            string
+	redisStreamGroups          map[string]map[string]map[string]bool
+	redisStreamPools           map[string]string
+	localCacheInvalidationPool string
+	cacheStampedeLocker        string
+	redisCodecs                map[string]RedisCodec
+	redisCodecPools            map[string]*redisCodecSetting
+	redisPrefixes              map[string]string
+	redisScripts               map[string]string
 }
 
 func (r *Registry) Validate() (ValidatedRegistry, error) {
@@ -131,6 +137,36 @@ func (r *Registry) Validate() (ValidatedRegistry, error) {
 	for k, v := range r.redisServers {
 		registry.redisServers[k] = v
 	}
+	for pool, prefix := range r.redisPrefixes {
+		config, has := registry.redisServers[pool]
+		if !has {
+			return nil, fmt.Errorf("redis pool '%s' not found", pool)
+		}
+		config.prefix = prefix
+	}
+	if r.localCacheInvalidationPool != "" {
+		_, has := registry.redisServers[r.localCacheInvalidationPool]
+		if !has {
+			return nil, fmt.Errorf("local cache invalidation redis pool '%s' not found", r.localCacheInvalidationPool)
+		}
+		registry.localCacheInvalidator = newLocalCacheInvalidator(registry, r.localCacheInvalidationPool)
+	}
+	if r.cacheStampedeLocker != "" {
+		_, has := registry.lockServers[r.cacheStampedeLocker]
+		if !has {
+			return nil, fmt.Errorf("cache stampede locker '%s' not found", r.cacheStampedeLocker)
+		}
+	}
+	registry.stampedeProtection = newStampedeProtection(r.cacheStampedeLocker)
+	err := r.validateRedisCodecs(registry)
+	if err != nil {
+		return nil, err
+	}
+	err = r.validateRedisScripts(registry)
+	if err != nil {
+		return nil, err
+	}
+	registry.cacheMetrics = newCacheMetrics()
 	if registry.elasticServers == nil {
 		registry.elasticServers = make(map[string]*ElasticConfig)
 	}
@@ -162,6 +198,9 @@ func (r *Registry) Validate() (ValidatedRegistry, error) {
 			return nil, fmt.Errorf("duplicated table cache prefix %s and %s", tableSchema.tableName, duplicated.tableName)
 		}
 		cachePrefixes[tableSchema.cachePrefix] = tableSchema
+		if tableSchema.localCacheTTL > 0 {
+			registry.localCacheContainers[tableSchema.localCacheName].setTTL(tableSchema.cachePrefix, tableSchema.localCacheTTL)
+		}
 		registry.entities[name] = entityType
 		_, has = r.redisStreamPools[lazyChannelName]
 		if !has {
@@ -285,14 +324,24 @@ func (r *Registry) RegisterElasticWithTraceLog(url string, code ...string) {
 }
 
 func (r *Registry) RegisterLocalCache(size int, code ...string) {
+	r.RegisterLocalCacheWithOptions(LocalCacheOptions{Size: size}, code...)
+}
+
+func (r *Registry) RegisterLocalCacheWithOptions(options LocalCacheOptions, code ...string) {
 	dbCode := "default"
 	if len(code) > 0 {
 		dbCode = code[0]
 	}
+	if options.Size < 0 || options.Shards < 0 || options.MaxBytes < 0 {
+		panic(fmt.Errorf("local cache %s options can't be negative", dbCode))
+	}
+	if options.Size == 0 && options.MaxBytes == 0 {
+		panic(fmt.Errorf("local cache %s requires size or max bytes", dbCode))
+	}
 	if r.localCacheContainers == nil {
 		r.localCacheContainers = make(map[string]*LocalCacheConfig)
 	}
-	r.localCacheContainers[dbCode] = &LocalCacheConfig{code: dbCode, lru: lru.New(size)}
+	r.localCacheContainers[dbCode] = newLocalCacheConfig(dbCode, options)
 }
 
 func (r *Registry) RegisterRedis(address string, db int, code ...string) {
@@ -314,6 +363,14 @@ func (r *Registry) RegisterRedisSentinel(masterName string, db int, sentinels []
 	r.registerRedis(client, code, fmt.Sprintf("%v", sentinels))
 }
 
+func (r *Registry) RegisterRedisCluster(addresses []string, code ...string) {
+	client := redis.NewClusterClient(&redis.ClusterOptions{
+		Addrs:      addresses,
+		MaxConnAge: time.Minute * 2,
+	})
+	r.registerRedis(client, code, fmt.Sprintf("%v", addresses))
+}
+
 func (r *Registry) RegisterRedisStream(name string, redisPool string, groups []string) {
 	if r.redisStreamGroups == nil {
 		r.redisStreamGroups = make(map[string]map[string]map[string]bool)
@@ -334,6 +391,49 @@ func (r *Registry) RegisterRedisStream(name string, redisPool string, groups []s
 	r.redisStreamGroups[redisPool][name] = groupsMap
 }
 
+func (r *Registry) RegisterLocalCacheInvalidation(redisCode ...string) {
+	r.localCacheInvalidationPool = "default"
+	if len(redisCode) > 0 {
+		r.localCacheInvalidationPool = redisCode[0]
+	}
+}
+
+func (r *Registry) RegisterCacheStampedeLocker(lockerCode ...string) {
+	r.cacheStampedeLocker = "default"
+	if len(lockerCode) > 0 {
+		r.cacheStampedeLocker = lockerCode[0]
+	}
+}
+
+func (r *Registry) RegisterRedisCodec(codec RedisCodec) {
+	if r.redisCodecs == nil {
+		r.redisCodecs = make(map[string]RedisCodec)
+	}
+	r.redisCodecs[codec.Name()] = codec
+}
+
+func (r *Registry) SetRedisCodec(codec string, compressAbove int, redisCode ...string) {
+	dbCode := "default"
+	if len(redisCode) > 0 {
+		dbCode = redisCode[0]
+	}
+	if r.redisCodecPools == nil {
+		r.redisCodecPools = make(map[string]*redisCodecSetting)
+	}
+	r.redisCodecPools[dbCode] = &redisCodecSetting{codec: codec, compressAbove: compressAbove}
+}
+
+func (r *Registry) SetRedisPrefix(prefix string, redisCode ...string) {
+	dbCode := "default"
+	if len(redisCode) > 0 {
+		dbCode = redisCode[0]
+	}
+	if r.redisPrefixes == nil {
+		r.redisPrefixes = make(map[string]string)
+	}
+	r.redisPrefixes[dbCode] = prefix
+}
+
 func (r *Registry) RegisterLocker(code string, redisCode string) {
 	if r.locks == nil {
 		r.locks = make(map[string]string)
@@ -405,12 +505,13 @@ func (r *Registry) registerElastic(url string, withTrace bool, code ...string) {
 	r.elasticServers[dbCode] = config
 }
 
-func (r *Registry) registerRedis(client *redis.Client, code []string, address string) {
+func (r *Registry) registerRedis(client redis.UniversalClient, code []string, address string) {
 	dbCode := "default"
 	if len(code) > 0 {
 		dbCode = code[0]
 	}
-	redisCache := &RedisCacheConfig{code: dbCode, client: client, address: address}
+	_, cluster := client.(*redis.ClusterClient)
+	redisCache := &RedisCacheConfig{code: dbCode, client: client, address: address, cluster: cluster}
 	if r.redisServers == nil {
 		r.redisServers = make(map[string]*RedisCacheConfig)
 	}
@@ -419,8 +520,20 @@ func (r *Registry) registerRedis(client *redis.Client, code []string, address st
 
 type RedisCacheConfig struct {
 	code    string
-	client  *redis.Client
+	client  redis.UniversalClient
 	address string
+	cluster bool
+	prefix  string
+}
+
+func (c *RedisCacheConfig) clientWithContext(ctx context.Context) redis.UniversalClient {
+	switch client := c.client.(type) {
+	case *redis.Client:
+		return client.WithContext(ctx)
+	case *redis.ClusterClient:
+		return client.WithContext(ctx)
+	}
+	return c.client
 }
 
 type ElasticConfig struct {
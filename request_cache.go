@@ -0,0 +1,84 @@
+package orm
+
+import (
+	"strconv"
+
+	"github.com/segmentio/fasthash/fnv1a"
+)
+
+const requestCacheSearchPrefix = "_search:"
+const requestCacheElasticPrefix = "_elastic"
+
+type requestCacheSearchResult struct {
+	total uint64
+	rows  []interface{}
+}
+
+type requestCacheMemoGroup struct {
+	values map[string]interface{}
+}
+
+func requestCacheMemo(engine *Engine, group string, query string, compute func() interface{}) interface{} {
+	if !engine.hasRequestCache {
+		return compute()
+	}
+	cache := engine.GetLocalCache(requestCacheKey)
+	key := strconv.FormatUint(fnv1a.HashString64(query), 36)
+	engine.requestCacheMutex.Lock()
+	memo := getRequestCacheMemoGroup(cache, group)
+	value, has := memo.values[key]
+	engine.requestCacheMutex.Unlock()
+	if has {
+		return value
+	}
+	value = compute()
+	engine.requestCacheMutex.Lock()
+	defer engine.requestCacheMutex.Unlock()
+	current, has := cache.Get(group)
+	if has && current.(*requestCacheMemoGroup) == memo {
+		memo.values[key] = value
+	}
+	return value
+}
+
+func getRequestCacheMemoGroup(cache *LocalCache, group string) *requestCacheMemoGroup {
+	value, has := cache.Get(group)
+	if has {
+		return value.(*requestCacheMemoGroup)
+	}
+	memo := &requestCacheMemoGroup{values: make(map[string]interface{})}
+	cache.Set(group, memo)
+	return memo
+}
+
+func copyRequestCacheRows(rows []interface{}) []interface{} {
+	copied := make([]interface{}, len(rows))
+	for i, row := range rows {
+		fields, is := row.([]interface{})
+		if is {
+			row = copyRequestCacheRows(fields)
+		}
+		copied[i] = row
+	}
+	return copied
+}
+
+func clearRequestCacheSearches(engine *Engine, schema *tableSchema) {
+	if !engine.hasRequestCache {
+		return
+	}
+	keys := make([]string, 0, len(engine.registry.elasticServers)+1)
+	if schema.hasSearchCache {
+		keys = append(keys, getRequestCacheSearchGroup(schema.searchCacheName, schema.redisSearchIndex.Name))
+	}
+	for code := range engine.registry.elasticServers {
+		keys = append(keys, requestCacheElasticPrefix+code)
+	}
+	if len(keys) > 0 {
+		engine.GetLocalCache(requestCacheKey).Remove(keys...)
+	}
+}
+
+func getRequestCacheSearchGroup(pool, index string) string {
+	return requestCacheSearchPrefix + pool + ":" + index
+}
@@ -1,6 +1,9 @@
 package orm
 
 import (
+	"context"
+	"strconv"
+	"sync/atomic"
 	"testing"
 
 	apexLog "github.com/apex/log"
@@ -18,6 +21,50 @@ type requestCacheEntity struct {
 	IndexCode *CachedQuery `queryOne:":Code = ?"`
 }
 
+type requestCacheSearchEntity struct {
+	ORM  `orm:"redisSearch=search"`
+	ID   uint
+	Age  uint64 `orm:"searchable;sortable"`
+	Name string
+}
+
+func TestRequestCacheRedisSearch(t *testing.T) {
+	var entity *requestCacheSearchEntity
+	engine := PrepareTables(t, &Registry{}, 5, entity)
+	indexer := NewRedisSearchIndexer(engine)
+	indexer.DisableLoop()
+	indexer.Run(context.Background())
+	engine.FlushMany(&requestCacheSearchEntity{Age: 10}, &requestCacheSearchEntity{Age: 20})
+
+	engine.EnableRequestCache(false)
+	redisLogger := memory.New()
+	engine.AddQueryLogger(redisLogger, apexLog.InfoLevel, QueryLoggerSourceRedis)
+
+	query := &RedisSearchQuery{}
+	query.FilterIntGreaterEqual("Age", 10)
+	ids, total := engine.RedisSearchIds(entity, query, NewPager(1, 10))
+	assert.Equal(t, uint64(2), total)
+	assert.Equal(t, []uint64{1, 2}, ids)
+	assert.Len(t, redisLogger.Entries, 1)
+	ids, total = engine.RedisSearchIds(entity, query, NewPager(1, 10))
+	assert.Equal(t, uint64(2), total)
+	assert.Len(t, ids, 2)
+	schema := engine.GetRegistry().GetTableSchemaForEntity(entity).(*tableSchema)
+	total, _ = engine.GetRedisSearch("search").Search(schema.redisSearchIndex.Name, query, NewPager(1, 10))
+	assert.Equal(t, uint64(2), total)
+	assert.Len(t, redisLogger.Entries, 2)
+
+	engine.Flush(&requestCacheSearchEntity{Age: 30})
+	entries := len(redisLogger.Entries)
+	_, total = engine.RedisSearchIds(entity, query, NewPager(1, 10))
+	assert.Equal(t, uint64(3), total)
+	assert.Len(t, redisLogger.Entries, entries+1)
+
+	engine.EnableRequestCache(true)
+	_, _ = engine.RedisSearchIds(entity, query, NewPager(1, 10))
+	assert.Len(t, redisLogger.Entries, entries+2)
+}
+
 func TestRequestCache(t *testing.T) {
 	var entity *requestCacheEntity
 	engine := PrepareTables(t, &Registry{}, 5, entity)
@@ -134,3 +181,38 @@ func TestRequestCache(t *testing.T) {
 	assert.Len(t, DBLogger.Entries, 1)
 	assert.Len(t, redisLogger.Entries, 2)
 }
+
+func TestRequestCacheMemo(t *testing.T) {
+	validatedRegistry, err := (&Registry{}).Validate()
+	assert.Nil(t, err)
+	engine := validatedRegistry.CreateEngine()
+	engine.EnableRequestCache(false)
+
+	var computed int64
+	tasks := make([]func(), 0)
+	for i := 0; i < 50; i++ {
+		query := strconv.Itoa(i % 5)
+		tasks = append(tasks, func() {
+			requestCacheMemo(engine, "group", query, func() interface{} {
+				atomic.AddInt64(&computed, 1)
+				return query
+			})
+		})
+	}
+	runParallel(10, tasks...)
+	assert.GreaterOrEqual(t, atomic.LoadInt64(&computed), int64(5))
+	computed = 0
+	for i := 0; i < 5; i++ {
+		assert.Equal(t, strconv.Itoa(i), requestCacheMemo(engine, "group", strconv.Itoa(i), func() interface{} {
+			computed++
+			return nil
+		}))
+	}
+	assert.Equal(t, int64(0), computed)
+
+	rows := []interface{}{"key", []interface{}{"field", "value"}}
+	copied := copyRequestCacheRows(rows)
+	copied[0] = "changed"
+	copied[1].([]interface{})[1] = "changed"
+	assert.Equal(t, []interface{}{"key", []interface{}{"field", "value"}}, rows)
+}
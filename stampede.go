@@ -0,0 +1,127 @@
+package orm
+
+import (
+	"math"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/golang/groupcache/lru"
+)
+
+const stampedeLockPrefix = "orm:stampede:"
+const stampedeLockTTL = time.Second * 5
+const stampedeLockWaitTimeout = time.Second * 3
+const stampedeCheckInterval = time.Millisecond * 20
+const stampedeEarlyRefreshBeta = 1.0
+
+type stampedeCall struct {
+	wg        sync.WaitGroup
+	value     interface{}
+	fresh     bool
+	recovered interface{}
+}
+
+type stampedeProtection struct {
+	mutex      sync.Mutex
+	calls      map[string]*stampedeCall
+	deltas     *lru.Cache
+	lockerCode string
+}
+
+func newStampedeProtection(lockerCode string) *stampedeProtection {
+	return &stampedeProtection{calls: make(map[string]*stampedeCall), deltas: lru.New(10000), lockerCode: lockerCode}
+}
+
+func (s *stampedeProtection) do(engine *Engine, key string, check func() (interface{}, bool),
+	compute func() interface{}) (value interface{}, fresh bool) {
+	if s == nil {
+		return compute(), true
+	}
+	s.mutex.Lock()
+	call, has := s.calls[key]
+	if has {
+		s.mutex.Unlock()
+		call.wg.Wait()
+		if call.recovered != nil {
+			panic(call.recovered)
+		}
+		return call.value, false
+	}
+	call = &stampedeCall{}
+	call.wg.Add(1)
+	s.calls[key] = call
+	s.mutex.Unlock()
+	defer func() {
+		if r := recover(); r != nil {
+			call.recovered = r
+		}
+		s.mutex.Lock()
+		delete(s.calls, key)
+		s.mutex.Unlock()
+		call.wg.Done()
+		if call.recovered != nil {
+			panic(call.recovered)
+		}
+	}()
+	call.value, call.fresh = s.recompute(engine, key, check, compute)
+	return call.value, call.fresh
+}
+
+func (s *stampedeProtection) recompute(engine *Engine, key string, check func() (interface{}, bool),
+	compute func() interface{}) (value interface{}, fresh bool) {
+	if s.lockerCode == "" || check == nil {
+		return compute(), true
+	}
+	lock, obtained := engine.GetLocker(s.lockerCode).Obtain(engine.context, stampedeLockPrefix+key, stampedeLockTTL, 0)
+	if obtained {
+		defer lock.Release()
+		value, has := check()
+		if has {
+			return value, false
+		}
+		return compute(), true
+	}
+	deadline := time.Now().Add(stampedeLockWaitTimeout)
+	for time.Now().Before(deadline) {
+		time.Sleep(stampedeCheckInterval)
+		value, has := check()
+		if has {
+			return value, false
+		}
+	}
+	return compute(), true
+}
+
+func (s *stampedeProtection) setDelta(key string, delta time.Duration) {
+	if s == nil {
+		return
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.deltas.Add(key, delta)
+}
+
+func (s *stampedeProtection) getDelta(key string) (delta time.Duration, has bool) {
+	if s == nil {
+		return 0, false
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	value, has := s.deltas.Get(key)
+	if !has {
+		return 0, false
+	}
+	return value.(time.Duration), true
+}
+
+func refreshEarly(delta time.Duration, remaining time.Duration) bool {
+	if remaining <= 0 {
+		return true
+	}
+	if delta <= 0 {
+		return false
+	}
+	/* #nosec */
+	return float64(delta)*stampedeEarlyRefreshBeta*-math.Log(rand.Float64()) >= float64(remaining)
+}
@@ -0,0 +1,159 @@
+package orm
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type stampedeEntity struct {
+	ORM  `orm:"localCache;redisCache"`
+	ID   uint
+	Name string
+}
+
+func TestStampedeSingleFlight(t *testing.T) {
+	registry := &Registry{}
+	registry.RegisterRedis("localhost:6381", 15)
+	registry.RegisterLocalCache(100)
+	validatedRegistry, err := registry.Validate()
+	assert.Nil(t, err)
+	engine := validatedRegistry.CreateEngine()
+	engine.GetRedis().FlushDB()
+
+	var calls int32
+	var wg sync.WaitGroup
+	results := make([]interface{}, 10)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			engine := validatedRegistry.CreateEngine()
+			results[i] = engine.GetLocalCache().GetSet("stampede_local", 10, func() interface{} {
+				atomic.AddInt32(&calls, 1)
+				time.Sleep(time.Millisecond * 200)
+				return "local"
+			})
+		}(i)
+	}
+	wg.Wait()
+	assert.Equal(t, int32(1), calls)
+	for _, result := range results {
+		assert.Equal(t, "local", result)
+	}
+
+	calls = 0
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			engine := validatedRegistry.CreateEngine()
+			results[i] = engine.GetRedis().GetSet("stampede_redis", 10, func() interface{} {
+				atomic.AddInt32(&calls, 1)
+				time.Sleep(time.Millisecond * 200)
+				return "redis"
+			})
+		}(i)
+	}
+	wg.Wait()
+	assert.Equal(t, int32(1), calls)
+	for _, result := range results {
+		assert.Equal(t, "redis", result)
+	}
+
+	protection := engine.registry.stampedeProtection
+	release := make(chan struct{})
+	go func() {
+		time.Sleep(time.Millisecond * 100)
+		close(release)
+	}()
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.PanicsWithError(t, "compute failed", func() {
+				protection.do(engine, "stampede_panic", nil, func() interface{} {
+					<-release
+					panic(errors.New("compute failed"))
+				})
+			})
+		}()
+	}
+	wg.Wait()
+	assert.Len(t, protection.calls, 0)
+
+	assert.True(t, refreshEarly(time.Second, 0))
+	assert.False(t, refreshEarly(0, time.Second))
+	assert.False(t, refreshEarly(time.Nanosecond, time.Hour))
+}
+
+func TestStampedeLoadByID(t *testing.T) {
+	var entity *stampedeEntity
+	engine := PrepareTables(t, &Registry{}, 5, entity)
+	engine.Flush(&stampedeEntity{Name: "a"})
+	engine.GetLocalCache().Clear()
+	engine.GetRedis().FlushDB()
+
+	var wg sync.WaitGroup
+	results := make([]*stampedeEntity, 10)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			entity := &stampedeEntity{}
+			engine.GetRegistry().CreateEngine().LoadByID(1, entity)
+			results[i] = entity
+		}(i)
+	}
+	wg.Wait()
+	for _, result := range results {
+		assert.Equal(t, uint(1), result.ID)
+		assert.Equal(t, "a", result.Name)
+	}
+	results[0].Name = "b"
+	assert.Equal(t, "a", results[1].Name)
+
+	engine.GetLocalCache().Clear()
+	engine.GetRedis().FlushDB()
+	var rows []*stampedeEntity
+	missing := engine.LoadByIDs([]uint64{1, 2}, &rows)
+	assert.Len(t, rows, 1)
+	assert.Equal(t, []uint64{2}, missing)
+	_, has := engine.GetRedis().Get(engine.GetRegistry().GetTableSchemaForEntity(entity).(*tableSchema).getCacheKey(2))
+	assert.True(t, has)
+}
+
+func TestStampedeLocker(t *testing.T) {
+	registry := &Registry{}
+	registry.RegisterRedis("localhost:6381", 15)
+	registry.RegisterLocker("default", "default")
+	registry.RegisterCacheStampedeLocker()
+	validatedRegistry, err := registry.Validate()
+	assert.Nil(t, err)
+	engine := validatedRegistry.CreateEngine()
+	engine.GetRedis().FlushDB()
+
+	lock, has := engine.GetLocker().Obtain(engine.context, stampedeLockPrefix+"redis:default:stampede_lock", time.Second*5, 0)
+	assert.True(t, has)
+	go func() {
+		time.Sleep(time.Millisecond * 100)
+		engine.GetRedis().Set("stampede_lock", `"from other instance"`, 10)
+		lock.Release()
+	}()
+	calls := 0
+	val := engine.GetRedis().GetSet("stampede_lock", 10, func() interface{} {
+		calls++
+		return "computed"
+	})
+	assert.Equal(t, "from other instance", val)
+	assert.Equal(t, 0, calls)
+
+	registry = &Registry{}
+	registry.RegisterCacheStampedeLocker("missing")
+	_, err = registry.Validate()
+	assert.EqualError(t, err, "cache stampede locker 'missing' not found")
+}
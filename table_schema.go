@@ -14,11 +14,19 @@ import (
 type CachedQuery struct{}
 
 type cachedQueryDefinition struct {
-	Max           int
-	Query         string
-	TrackedFields []string
-	QueryFields   []string
-	OrderFields   []string
+	Max            int
+	Query          string
+	TrackedFields  []string
+	QueryFields    []string
+	OrderFields    []string
+	InArguments    []int
+	RangeArguments []int
+	RangeFields    []string
+	RangeOperators []string
+	Bucket         float64
+	OrderDesc      bool
+	DBFallback     bool
+	DBQuery        string
 }
 
 type Enum interface {
@@ -90,6 +98,7 @@ type tableSchema struct {
 	cachedIndexes        map[string]*cachedQueryDefinition
 	cachedIndexesOne     map[string]*cachedQueryDefinition
 	cachedIndexesAll     map[string]*cachedQueryDefinition
+	cachedAggregates     map[string]*cachedAggregateDefinition
 	columnNames          []string
 	columnMapping        map[string]int
 	uniqueIndices        map[string][]string
@@ -98,8 +107,13 @@ type tableSchema struct {
 	refMany              []string
 	localCacheName       string
 	hasLocalCache        bool
+	localCacheTTL        time.Duration
 	redisCacheName       string
 	hasRedisCache        bool
+	redisCacheTTL        time.Duration
+	redisCodec           *redisCodecConfig
+	bloomFilter          *bloomFilter
+	writeBehind          bool
 	searchCacheName      string
 	hasSearchCache       bool
 	cachePrefix          string
@@ -284,6 +298,29 @@ func initTableSchema(registry *Registry, entityType reflect.Type) (*tableSchema,
 			return nil, fmt.Errorf("redis pool '%s' not found", redisCache)
 		}
 	}
+	localCacheTTL, err := parseCacheTTL(tags, "localCacheTTL", localCache, entityType)
+	if err != nil {
+		return nil, err
+	}
+	redisCacheTTL, err := parseCacheTTL(tags, "redisCacheTTL", redisCache, entityType)
+	if err != nil {
+		return nil, err
+	}
+	if redisCacheTTL > 0 && redisCacheTTL < time.Second {
+		return nil, fmt.Errorf("redisCacheTTL in %s must be at least 1s", entityType.String())
+	}
+	redisCodec, err := parseRedisCodec(registry, tags, redisCache, entityType)
+	if err != nil {
+		return nil, err
+	}
+	bloomFilter, err := parseBloomFilter(tags, redisCache, entityType)
+	if err != nil {
+		return nil, err
+	}
+	writeBehind, err := parseWriteBehind(tags, redisCache, entityType)
+	if err != nil {
+		return nil, err
+	}
 	userValue, has = tags["ORM"]["redisSearch"]
 	if has {
 		if userValue == "true" {
@@ -307,6 +344,7 @@ func initTableSchema(registry *Registry, entityType reflect.Type) (*tableSchema,
 	cachedQueries := make(map[string]*cachedQueryDefinition)
 	cachedQueriesOne := make(map[string]*cachedQueryDefinition)
 	cachedQueriesAll := make(map[string]*cachedQueryDefinition)
+	cachedAggregates := make(map[string]*cachedAggregateDefinition)
 	hasFakeDelete := false
 	fakeDeleteField, has := entityType.FieldByName("FakeDelete")
 	if has && fakeDeleteField.Type.String() == "bool" {
@@ -325,6 +363,7 @@ func initTableSchema(registry *Registry, entityType reflect.Type) (*tableSchema,
 		fieldsQuery := make([]string, 0)
 		fieldsOrder := make([]string, 0)
 		if has {
+			inArguments, rangeArguments, rangeFields, rangeOperators := parseCachedQueryArguments(queryOrigin)
 			re := regexp.MustCompile(":([A-Za-z0-9])+")
 			variables := re.FindAllString(query, -1)
 			for _, variable := range variables {
@@ -353,6 +392,8 @@ func initTableSchema(registry *Registry, entityType reflect.Type) (*tableSchema,
 			} else if hasFakeDelete {
 				query = "`FakeDelete` = 0 AND " + query
 			}
+			dbQuery := query
+			query = strings.ReplaceAll(query, " IN ?", " = ?")
 			queryLower := strings.ToLower(queryOrigin)
 			posOrderBy := strings.Index(queryLower, "order by")
 			for _, f := range fields {
@@ -373,15 +414,55 @@ func initTableSchema(registry *Registry, entityType reflect.Type) (*tableSchema,
 			}
 
 			if !isOne {
-				def := &cachedQueryDefinition{50000, query, fieldsTracked, fieldsQuery, fieldsOrder}
+				def := &cachedQueryDefinition{Max: 50000, Query: query, TrackedFields: fieldsTracked, QueryFields: fieldsQuery,
+					OrderFields: fieldsOrder, InArguments: inArguments, RangeArguments: rangeArguments, RangeFields: rangeFields, RangeOperators: rangeOperators, DBQuery: dbQuery}
+				maxRows, hasMax := values["max"]
+				if hasMax {
+					def.Max, err = strconv.Atoi(maxRows)
+					if err != nil || def.Max <= 0 {
+						return nil, fmt.Errorf("invalid max '%s' for cached query %s in %s", maxRows, key, entityType.String())
+					}
+				}
+				_, def.DBFallback = values["dbFallback"]
+				if len(inArguments) > 0 {
+					if len(fieldsOrder) > 1 || (len(fieldsOrder) == 1 && fieldsOrder[0] != "ID") {
+						return nil, fmt.Errorf("cached query %s with IN parameter in %s can be sorted only by ID", key, entityType.String())
+					}
+					def.OrderDesc = posOrderBy > -1 && strings.Contains(queryLower[posOrderBy:], "desc")
+				}
+				bucket, hasBucket := values["bucket"]
+				if hasBucket {
+					def.Bucket, err = strconv.ParseFloat(bucket, 64)
+					if err != nil || def.Bucket <= 0 || len(rangeArguments) == 0 {
+						return nil, fmt.Errorf("invalid bucket '%s' for cached query %s in %s", bucket, key, entityType.String())
+					}
+					if strings.Contains(queryLower, " or ") {
+						return nil, fmt.Errorf("bucket can't be used with OR in cached query %s in %s", key, entityType.String())
+					}
+				}
 				cachedQueries[key] = def
 				cachedQueriesAll[key] = def
 			} else {
-				def := &cachedQueryDefinition{1, query, fieldsTracked, fieldsQuery, fieldsOrder}
+				if len(inArguments) > 0 || len(rangeArguments) > 0 {
+					return nil, fmt.Errorf("IN and range parameters are not allowed in queryOne %s in %s", key, entityType.String())
+				}
+				_, hasMax := values["max"]
+				_, hasFallback := values["dbFallback"]
+				if hasMax || hasFallback {
+					return nil, fmt.Errorf("max and dbFallback are not allowed in queryOne %s in %s", key, entityType.String())
+				}
+				def := &cachedQueryDefinition{Max: 1, Query: query, TrackedFields: fieldsTracked, QueryFields: fieldsQuery, OrderFields: fieldsOrder}
 				cachedQueriesOne[key] = def
 				cachedQueriesAll[key] = def
 			}
 		}
+		aggregate, err := parseCachedAggregate(key, values, hasFakeDelete, redisCache, entityType)
+		if err != nil {
+			return nil, err
+		}
+		if aggregate != nil {
+			cachedAggregates[key] = aggregate
+		}
 		_, has = values["ref"]
 		if has {
 			oneRefs = append(oneRefs, key)
@@ -501,6 +582,12 @@ func initTableSchema(registry *Registry, entityType reflect.Type) (*tableSchema,
 	}
 	cachePrefix = fmt.Sprintf("%x", sha256.Sum256([]byte(cachePrefix+fieldsQuery)))
 	cachePrefix = cachePrefix[0:5]
+	if redisCache != "" && registry.redisServers[redisCache].cluster {
+		cachePrefix = redisKeyHashTag(cachePrefix)
+	}
+	if bloomFilter != nil {
+		bloomFilter.key = cachePrefix + ":bloom"
+	}
 	if redisSearchIndex == nil {
 		redisSearch = ""
 	}
@@ -518,10 +605,16 @@ func initTableSchema(registry *Registry, entityType reflect.Type) (*tableSchema,
 		cachedIndexes:        cachedQueries,
 		cachedIndexesOne:     cachedQueriesOne,
 		cachedIndexesAll:     cachedQueriesAll,
+		cachedAggregates:     cachedAggregates,
 		localCacheName:       localCache,
 		hasLocalCache:        localCache != "",
+		localCacheTTL:        localCacheTTL,
 		redisCacheName:       redisCache,
 		hasRedisCache:        redisCache != "",
+		redisCacheTTL:        redisCacheTTL,
+		redisCodec:           redisCodec,
+		bloomFilter:          bloomFilter,
+		writeBehind:          writeBehind,
 		searchCacheName:      redisSearch,
 		hasSearchCache:       redisSearchIndex != nil,
 		refOne:               oneRefs,
@@ -824,6 +917,15 @@ func extractTags(registry *Registry, entityType reflect.Type, prefix string) (fi
 			}
 			fields[field.Name]["queryOne"] = queryOne
 		}
+		for _, aggregateTag := range []string{"cachedCount", "cachedSum"} {
+			aggregate, hasAggregate := field.Tag.Lookup(aggregateTag)
+			if hasAggregate {
+				if fields[field.Name] == nil {
+					fields[field.Name] = make(map[string]string)
+				}
+				fields[field.Name][aggregateTag] = aggregate
+			}
+		}
 		if hasRef {
 			if fields[field.Name] == nil {
 				fields[field.Name] = make(map[string]string)
@@ -868,6 +970,51 @@ func (tableSchema *tableSchema) getCacheKey(id uint64) string {
 	return tableSchema.cachePrefix + ":" + strconv.FormatUint(id, 10)
 }
 
+func (tableSchema *tableSchema) redisSetTTL() time.Duration {
+	if tableSchema.writeBehind {
+		return redisSetNX
+	}
+	return tableSchema.redisCacheTTL
+}
+
+func (tableSchema *tableSchema) redisNilTTL() int {
+	if tableSchema.redisCacheTTL > 0 {
+		return int(tableSchema.redisCacheTTL / time.Second)
+	}
+	return 60
+}
+
+func parseCachedQueryArguments(query string) (inArguments, rangeArguments []int, rangeFields, rangeOperators []string) {
+	re := regexp.MustCompile(`:([A-Za-z0-9]+)\s*(>=|<=|>|<|IN)\s*\?`)
+	for _, match := range re.FindAllStringSubmatchIndex(query, -1) {
+		position := strings.Count(query[0:match[1]], "?") - 1
+		operator := query[match[4]:match[5]]
+		if operator == "IN" {
+			inArguments = append(inArguments, position)
+		} else {
+			rangeArguments = append(rangeArguments, position)
+			rangeFields = append(rangeFields, query[match[2]:match[3]])
+			rangeOperators = append(rangeOperators, operator)
+		}
+	}
+	return inArguments, rangeArguments, rangeFields, rangeOperators
+}
+
+func parseCacheTTL(tags map[string]map[string]string, tag string, pool string, entityType reflect.Type) (time.Duration, error) {
+	userValue, has := tags["ORM"][tag]
+	if !has {
+		return 0, nil
+	}
+	if pool == "" {
+		return 0, fmt.Errorf("%s defined in %s without cache pool", tag, entityType.String())
+	}
+	ttl, err := time.ParseDuration(userValue)
+	if err != nil || ttl <= 0 {
+		return 0, fmt.Errorf("invalid %s '%s' in %s", tag, userValue, entityType.String())
+	}
+	return ttl, nil
+}
+
 func (fields *tableFields) getColumnNames() []string {
 	columns := make([]string, 0)
 	ids := fields.uintegers
@@ -0,0 +1,45 @@
+package tools
+
+import (
+	"sort"
+
+	"github.com/summer-solutions/orm"
+)
+
+type CacheStatistics struct {
+	Entity   string
+	Query    string
+	Layers   []*orm.CacheMetric
+	Hits     uint64
+	DBLoads  uint64
+	HitRatio float64
+}
+
+func GetCacheStatistics(engine *orm.Engine) []*CacheStatistics {
+	results := make([]*CacheStatistics, 0)
+	grouped := make(map[string]*CacheStatistics)
+	for _, metric := range engine.GetCacheMetrics() {
+		key := metric.Entity + ":" + metric.Query
+		stat, has := grouped[key]
+		if !has {
+			stat = &CacheStatistics{Entity: metric.Entity, Query: metric.Query, Layers: make([]*orm.CacheMetric, 0)}
+			grouped[key] = stat
+			results = append(results, stat)
+		}
+		stat.Layers = append(stat.Layers, metric)
+		if metric.Layer == orm.CacheLayerDB {
+			stat.DBLoads += metric.Hits + metric.Misses
+		} else {
+			stat.Hits += metric.Hits
+		}
+	}
+	for _, stat := range results {
+		if stat.Hits+stat.DBLoads > 0 {
+			stat.HitRatio = float64(stat.Hits) / float64(stat.Hits+stat.DBLoads)
+		}
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].DBLoads > results[j].DBLoads
+	})
+	return results
+}
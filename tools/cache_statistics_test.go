@@ -0,0 +1,60 @@
+package tools
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/summer-solutions/orm"
+)
+
+type cacheStatisticsEntity struct {
+	orm.ORM `orm:"localCache"`
+	ID      uint
+	Name    string
+}
+
+type cacheStatisticsNoCacheEntity struct {
+	orm.ORM
+	ID   uint
+	Name string
+}
+
+func TestCacheStatistics(t *testing.T) {
+	registry := &orm.Registry{}
+	registry.RegisterMySQLPool("root:root@tcp(localhost:3311)/test")
+	registry.RegisterRedis("localhost:6381", 15)
+	registry.RegisterLocalCache(100)
+	registry.RegisterEntity(&cacheStatisticsEntity{}, &cacheStatisticsNoCacheEntity{})
+	validatedRegistry, err := registry.Validate()
+	assert.NoError(t, err)
+	engine := validatedRegistry.CreateEngine()
+	for _, alter := range engine.GetAlters() {
+		engine.GetMysql(alter.Pool).Exec(alter.SQL)
+	}
+	engine.GetMysql().Exec("TRUNCATE TABLE `cacheStatisticsEntity`")
+	engine.GetMysql().Exec("TRUNCATE TABLE `cacheStatisticsNoCacheEntity`")
+	engine.FlushMany(&cacheStatisticsEntity{Name: "a"}, &cacheStatisticsNoCacheEntity{Name: "a"})
+	engine.GetLocalCache().Clear()
+	engine.ResetCacheMetrics()
+
+	cached := &cacheStatisticsEntity{}
+	engine.LoadByID(1, cached)
+	engine.LoadByID(1, cached)
+	engine.LoadByID(1, cached)
+	notCached := &cacheStatisticsNoCacheEntity{}
+	engine.LoadByID(1, notCached)
+	engine.LoadByID(1, notCached)
+
+	stats := GetCacheStatistics(engine)
+	assert.Len(t, stats, 2)
+	assert.Equal(t, "tools.cacheStatisticsNoCacheEntity", stats[0].Entity)
+	assert.Equal(t, uint64(2), stats[0].DBLoads)
+	assert.Equal(t, uint64(0), stats[0].Hits)
+	assert.Equal(t, float64(0), stats[0].HitRatio)
+	assert.Len(t, stats[0].Layers, 1)
+	assert.Equal(t, "tools.cacheStatisticsEntity", stats[1].Entity)
+	assert.Equal(t, uint64(1), stats[1].DBLoads)
+	assert.Equal(t, uint64(2), stats[1].Hits)
+	assert.InDelta(t, 0.66, stats[1].HitRatio, 0.01)
+	assert.Len(t, stats[1].Layers, 2)
+}
@@ -0,0 +1,44 @@
+package tools
+
+import (
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/summer-solutions/orm"
+)
+
+type WarmUpResult struct {
+	Entity string
+	Rows   int
+	Time   time.Duration
+}
+
+func WarmUpCache(engine *orm.Engine, batchSize int, pause time.Duration, progress func(entity string, loaded int)) []*WarmUpResult {
+	registry := engine.GetRegistry()
+	names := make([]string, 0)
+	for name := range registry.GetEntities() {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	results := make([]*WarmUpResult, 0)
+	for _, name := range names {
+		schema := registry.GetTableSchema(name)
+		_, hasLocalCache := schema.GetLocalCache(engine)
+		_, hasRedis := schema.GetRedisCache(engine)
+		if !hasLocalCache && !hasRedis {
+			continue
+		}
+		options := &orm.WarmUpOptions{Pause: pause}
+		if progress != nil {
+			options.Progress = func(loaded int) {
+				progress(name, loaded)
+			}
+		}
+		start := time.Now()
+		entity := reflect.New(schema.GetType()).Interface().(orm.Entity)
+		rows := engine.WarmUpCache(entity, nil, batchSize, options)
+		results = append(results, &WarmUpResult{Entity: name, Rows: rows, Time: time.Since(start)})
+	}
+	return results
+}
@@ -0,0 +1,55 @@
+package tools
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/summer-solutions/orm"
+)
+
+type warmUpEntity struct {
+	orm.ORM `orm:"redisCache"`
+	ID      uint
+	Name    string
+}
+
+type warmUpNoCacheEntity struct {
+	orm.ORM
+	ID   uint
+	Name string
+}
+
+func TestWarmUpCache(t *testing.T) {
+	registry := &orm.Registry{}
+	registry.RegisterMySQLPool("root:root@tcp(localhost:3311)/test")
+	registry.RegisterRedis("localhost:6381", 15)
+	registry.RegisterEntity(&warmUpEntity{}, &warmUpNoCacheEntity{})
+	validatedRegistry, err := registry.Validate()
+	assert.NoError(t, err)
+	engine := validatedRegistry.CreateEngine()
+	for _, alter := range engine.GetAlters() {
+		engine.GetMysql(alter.Pool).Exec(alter.SQL)
+	}
+	engine.GetMysql().Exec("TRUNCATE TABLE `warmUpEntity`")
+	engine.FlushMany(&warmUpEntity{Name: "a"}, &warmUpEntity{Name: "b"}, &warmUpEntity{Name: "c"})
+	engine.GetRedis().FlushDB()
+
+	progress := make([]int, 0)
+	results := WarmUpCache(engine, 2, 0, func(entity string, loaded int) {
+		assert.Equal(t, "tools.warmUpEntity", entity)
+		progress = append(progress, loaded)
+	})
+	assert.Len(t, results, 1)
+	assert.Equal(t, "tools.warmUpEntity", results[0].Entity)
+	assert.Equal(t, 3, results[0].Rows)
+	assert.Equal(t, []int{2, 3}, progress)
+
+	engine.ResetCacheMetrics()
+	var rows []*warmUpEntity
+	engine.LoadByIDs([]uint64{1, 2, 3}, &rows)
+	assert.Len(t, rows, 3)
+	stats := GetCacheStatistics(engine)
+	assert.Len(t, stats, 1)
+	assert.Equal(t, uint64(3), stats[0].Hits)
+	assert.Equal(t, uint64(0), stats[0].DBLoads)
+}
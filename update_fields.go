@@ -0,0 +1,123 @@
+package orm
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+func updateFields(engine *Engine, entity Entity, id uint64, fields Bind) {
+	schema := initIfNeeded(engine, entity).tableSchema
+	if len(fields) == 0 {
+		return
+	}
+	for field := range fields {
+		_, has := schema.columnMapping[field]
+		if !has || field == "ID" {
+			panic(fmt.Errorf("invalid field %s in %s", field, schema.t.String()))
+		}
+	}
+	value := reflect.New(schema.t)
+	toUpdate := value.Interface().(Entity)
+	orm := initIfNeeded(engine, toUpdate)
+	if updateFieldsNeedsOldData(schema, fields) {
+		found, _, _ := loadByID(engine, id, toUpdate, true, true)
+		if !found {
+			panic(fmt.Errorf("entity %s with ID %d not found", schema.t.String(), id))
+		}
+		setUpdateFields(toUpdate, fields)
+		flush(engine, nil, nil, true, false, false, false, toUpdate)
+		return
+	}
+	orm.idElem.SetUint(id)
+	setUpdateFields(toUpdate, fields)
+	dirtyBind, _ := orm.GetDirtyBind()
+	bind := make(Bind)
+	columns := make([]string, 0, len(fields))
+	args := make([]interface{}, 0, len(fields)+1)
+	for field := range fields {
+		bind[field] = dirtyBind[field]
+		columns = append(columns, "`"+field+"` = ?")
+		args = append(args, dirtyBind[field])
+	}
+	args = append(args, id)
+	/* #nosec */
+	sql := "UPDATE `" + schema.tableName + "` SET " + strings.Join(columns, ",") + " WHERE `ID` = ?"
+	db := schema.GetMysql(engine)
+	if db.Exec(sql, args...).RowsAffected() == 0 {
+		/* #nosec */
+		query := NewWhere("SELECT `ID` FROM `"+schema.tableName+"` WHERE `ID` = ?", id)
+		var found uint64
+		if !db.QueryRow(query, &found) {
+			panic(fmt.Errorf("entity %s with ID %d not found", schema.t.String(), id))
+		}
+	}
+
+	cacheKey := schema.getCacheKey(id)
+	localCache, hasLocalCache := schema.GetLocalCache(engine)
+	if !hasLocalCache && engine.hasRequestCache {
+		hasLocalCache = true
+		localCache = engine.GetLocalCache(requestCacheKey)
+	}
+	keys := append(getAdHocSearchTagsKeys(schema, bind, false), cacheKey)
+	if hasLocalCache {
+		localCache.Remove(keys...)
+		publishLocalCacheInvalidation(engine, localCache.code, keys...)
+	} else if engine.dataLoader != nil {
+		engine.dataLoader.remove(schema, id)
+	}
+	rFlusher := engine.afterCommitRedisFlusher
+	if rFlusher == nil {
+		rFlusher = &redisFlusher{engine: engine}
+	}
+	redisCache, hasRedis := schema.GetRedisCache(engine)
+	if hasRedis {
+		rFlusher.Del(redisCache.code, keys...)
+	}
+	fillRedisSearchFromBind(schema, rFlusher, bind, id)
+	clearRequestCacheSearches(engine, schema)
+	addDirtyQueues(rFlusher, bind, schema, id, "u")
+	if db.inTransaction {
+		engine.afterCommitRedisFlusher = rFlusher
+	} else {
+		rFlusher.Flush()
+	}
+}
+
+func updateFieldsNeedsOldData(schema *tableSchema, fields Bind) bool {
+	if schema.hasLog || schema.writeBehind {
+		return true
+	}
+	_, has := fields["FakeDelete"]
+	if has {
+		return true
+	}
+	for _, definition := range schema.cachedIndexesAll {
+		for _, trackedField := range definition.TrackedFields {
+			_, has := fields[trackedField]
+			if has {
+				return true
+			}
+		}
+	}
+	for _, definition := range schema.cachedAggregates {
+		_, has := fields[definition.SumField]
+		if has {
+			return true
+		}
+		for _, field := range definition.QueryFields {
+			_, has := fields[field]
+			if has {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func setUpdateFields(entity Entity, fields Bind) {
+	for field, value := range fields {
+		err := entity.SetField(field, value)
+		checkError(err)
+	}
+}
@@ -0,0 +1,83 @@
+package orm
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type updateFieldsEntity struct {
+	ORM      `orm:"localCache;redisCache"`
+	ID       uint
+	Name     string
+	Age      int `orm:"index=age"`
+	Balance  *int
+	Code     string       `orm:"unique=Code"`
+	IndexAge *CachedQuery `query:":Age = ?"`
+}
+
+func TestUpdateFields(t *testing.T) {
+	var entity *updateFieldsEntity
+	engine := PrepareTables(t, &Registry{}, 5, entity)
+
+	balance := 10
+	engine.Flush(&updateFieldsEntity{Name: "John", Age: 18, Balance: &balance})
+	entity = &updateFieldsEntity{}
+	assert.True(t, engine.LoadByID(1, entity))
+
+	assert.PanicsWithError(t, "invalid field Invalid in orm.updateFieldsEntity", func() {
+		engine.UpdateFields(entity, 1, Bind{"Invalid": "Tom"})
+	})
+	assert.PanicsWithError(t, "invalid field ID in orm.updateFieldsEntity", func() {
+		engine.UpdateFields(entity, 1, Bind{"ID": 2})
+	})
+	assert.PanicsWithError(t, "Age value test not valid", func() {
+		engine.UpdateFields(entity, 1, Bind{"Age": "test"})
+	})
+
+	engine.UpdateFields(entity, 1, Bind{"Name": "Tom", "Balance": nil})
+	entity = &updateFieldsEntity{}
+	assert.True(t, engine.LoadByID(1, entity))
+	assert.Equal(t, "Tom", entity.Name)
+	assert.Nil(t, entity.Balance)
+	assert.Equal(t, 18, entity.Age)
+	engine.GetLocalCache().Clear()
+	engine.GetRedis().FlushDB()
+	entity = &updateFieldsEntity{}
+	assert.True(t, engine.LoadByID(1, entity))
+	assert.Equal(t, "Tom", entity.Name)
+
+	var rows []*updateFieldsEntity
+	total := engine.CachedSearch(&rows, "IndexAge", nil, 18)
+	assert.Equal(t, 1, total)
+	engine.UpdateFields(entity, 1, Bind{"Age": "20"})
+	total = engine.CachedSearch(&rows, "IndexAge", nil, 18)
+	assert.Equal(t, 0, total)
+	total = engine.CachedSearch(&rows, "IndexAge", nil, 20)
+	assert.Equal(t, 1, total)
+	assert.Equal(t, "Tom", rows[0].Name)
+	entity = &updateFieldsEntity{}
+	assert.True(t, engine.LoadByID(1, entity))
+	assert.Equal(t, 20, entity.Age)
+
+	assert.PanicsWithError(t, "entity orm.updateFieldsEntity with ID 2 not found", func() {
+		engine.UpdateFields(entity, 2, Bind{"Age": 30})
+	})
+	assert.PanicsWithError(t, "entity orm.updateFieldsEntity with ID 2 not found", func() {
+		engine.UpdateFields(entity, 2, Bind{"Name": "Adam"})
+	})
+	assert.False(t, engine.LoadByID(2, entity))
+	engine.UpdateFields(entity, 1, Bind{"Name": "Tom"})
+
+	engine.Flush(&updateFieldsEntity{Name: "Adam", Code: "b"})
+	engine.UpdateFields(entity, 1, Bind{"Code": "a"})
+	for _, fields := range []Bind{{"Code": "a"}, {"Code": "a", "Age": 40}} {
+		func() {
+			defer func() {
+				_, is := recover().(*DuplicatedKeyError)
+				assert.True(t, is)
+			}()
+			engine.UpdateFields(entity, 2, fields)
+		}()
+	}
+}
@@ -0,0 +1,218 @@
+package orm
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type upsertGroup struct {
+	schema   *tableSchema
+	index    string
+	fields   []string
+	binds    []Bind
+	entities []Entity
+}
+
+func addToUpsertGroup(groups map[string]*upsertGroup, schema *tableSchema, orm *ORM, bind Bind, entity Entity) {
+	key := schema.t.String() + ":" + orm.upsertIndex + ":" + strings.Join(orm.upsertFields, ",")
+	group, has := groups[key]
+	if !has {
+		group = &upsertGroup{schema: schema, index: orm.upsertIndex, fields: orm.upsertFields}
+		groups[key] = group
+	}
+	group.binds = append(group.binds, bind)
+	group.entities = append(group.entities, entity)
+}
+
+func validateUpsert(schema *tableSchema, conflictIndex string, updateFields []string) {
+	_, has := schema.uniqueIndices[conflictIndex]
+	if !has {
+		panic(fmt.Errorf("unknown unique index %s in %s", conflictIndex, schema.t.String()))
+	}
+	for _, field := range updateFields {
+		_, has := schema.columnMapping[field]
+		if !has || field == "ID" {
+			panic(fmt.Errorf("invalid upsert field %s in %s", field, schema.t.String()))
+		}
+	}
+}
+
+func fillLazyUpsert(lazyMap map[string]interface{}, group *upsertGroup) {
+	upserts := lazyMap["u"]
+	if upserts == nil {
+		upserts = make([]interface{}, 0)
+	}
+	lazyValue := make([]interface{}, 4)
+	lazyValue[0] = group.schema.t.String()
+	lazyValue[1] = group.index
+	lazyValue[2] = group.fields
+	lazyValue[3] = group.binds
+	lazyMap["u"] = append(upserts.([]interface{}), lazyValue)
+}
+
+func flushUpsert(engine *Engine, group *upsertGroup, localCacheSets map[string]map[string][]interface{},
+	localCacheDeletes map[string]map[string]bool, redisFlusher RedisFlusher, dataLoaderSets dataLoaderSets) {
+	schema := group.schema
+	indexColumns := schema.uniqueIndices[group.index]
+	columnsMap := make(map[string]bool)
+	keys := make([]string, len(group.binds))
+	conflictValues := make([]interface{}, 0, len(group.binds)*len(indexColumns))
+	for i, bind := range group.binds {
+		values := make([]interface{}, len(indexColumns))
+		for j, column := range indexColumns {
+			value := bind[column]
+			if value == nil {
+				panic(fmt.Errorf("upsert index %s value %s in %s can't be nil", group.index, column, schema.t.String()))
+			}
+			values[j] = value
+		}
+		keys[i] = upsertKey(values)
+		conflictValues = append(conflictValues, values...)
+		for column := range bind {
+			columnsMap[column] = true
+		}
+	}
+	columns := make([]string, 0, len(columnsMap))
+	for column := range columnsMap {
+		columns = append(columns, column)
+	}
+	sort.Strings(columns)
+	escaped := make([]string, len(columns))
+	for i, column := range columns {
+		escaped[i] = "`" + column + "`"
+	}
+	row := "(" + strings.TrimLeft(strings.Repeat(",?", len(columns)), ",") + ")"
+	values := make([]string, len(group.binds))
+	args := make([]interface{}, 0, len(group.binds)*len(columns))
+	for i, bind := range group.binds {
+		values[i] = row
+		for _, column := range columns {
+			args = append(args, bind[column])
+		}
+	}
+	/* #nosec */
+	sql := "INSERT INTO `" + schema.tableName + "`(" + strings.Join(escaped, ",") + ") VALUES " + strings.Join(values, ",")
+	sql += " ON DUPLICATE KEY UPDATE "
+	if len(group.fields) == 0 {
+		sql += "`ID` = `ID`"
+	} else {
+		updates := make([]string, len(group.fields))
+		for i, field := range group.fields {
+			updates[i] = "`" + field + "` = VALUES(`" + field + "`)"
+		}
+		sql += strings.Join(updates, ",")
+	}
+
+	db := schema.GetMysql(engine)
+	inTransaction := db.inTransaction
+	if !inTransaction {
+		db.Begin()
+		defer db.Rollback()
+	}
+	before := searchUpsertRows(db, schema, indexColumns, conflictValues)
+	db.Exec(sql, args...)
+	after := searchUpsertRows(db, schema, indexColumns, conflictValues)
+	if !inTransaction {
+		db.Commit()
+	}
+
+	for i, entity := range group.entities {
+		data, has := after[keys[i]]
+		if !has {
+			continue
+		}
+		id := data[0].(uint64)
+		old, existed := before[keys[i]]
+		if !existed {
+			fillFromDBRow(id, engine, data, entity, false)
+			updateCacheForInserted(engine, entity, false, id, convertDBDataToMap(schema, data), localCacheSets, localCacheDeletes,
+				redisFlusher, dataLoaderSets)
+			continue
+		}
+		oldData := make([]interface{}, len(old))
+		copy(oldData, old)
+		fillFromDBRow(id, engine, oldData, entity, false)
+		changes := make(Bind)
+		for _, column := range schema.columnNames[1:] {
+			index := schema.columnMapping[column]
+			if old[index] != data[index] {
+				changes[column] = data[index]
+			}
+		}
+		if len(changes) > 0 {
+			updateCacheAfterUpdate(false, entity.getORM().dBData, engine, entity, changes, schema, localCacheSets, localCacheDeletes,
+				db, id, redisFlusher, dataLoaderSets)
+		}
+		fillFromDBRow(id, engine, data, entity, false)
+	}
+}
+
+func searchUpsertRows(db *DB, schema *tableSchema, indexColumns []string, values []interface{}) map[string][]interface{} {
+	l := len(indexColumns)
+	escaped := make([]string, l)
+	for i, column := range indexColumns {
+		escaped[i] = "`" + column + "`"
+	}
+	var where string
+	if l == 1 {
+		where = escaped[0] + " IN (" + strings.TrimLeft(strings.Repeat(",?", len(values)), ",") + ")"
+	} else {
+		row := "(" + strings.TrimLeft(strings.Repeat(",?", l), ",") + ")"
+		rows := make([]string, len(values)/l)
+		for i := range rows {
+			rows[i] = row
+		}
+		where = "(" + strings.Join(escaped, ",") + ") IN (" + strings.Join(rows, ",") + ")"
+	}
+	/* #nosec */
+	query := "SELECT " + schema.fieldsQuery + " FROM `" + schema.tableName + "` WHERE " + where
+	results, def := db.Query(query, values...)
+	defer def()
+	rows := make(map[string][]interface{})
+	for results.Next() {
+		pointers := prepareScan(schema)
+		results.Scan(pointers...)
+		convertScan(schema.fields, 0, pointers)
+		key := make([]interface{}, l)
+		for i, column := range indexColumns {
+			key[i] = pointers[schema.columnMapping[column]]
+		}
+		rows[upsertKey(key)] = pointers
+	}
+	def()
+	return rows
+}
+
+func upsertKey(values []interface{}) string {
+	parts := make([]string, len(values))
+	for i, value := range values {
+		asFloat, isFloat := value.(float64)
+		if isFloat {
+			parts[i] = strconv.FormatFloat(asFloat, 'f', -1, 64)
+		} else {
+			parts[i] = strings.ToLower(fmt.Sprintf("%v", value))
+		}
+	}
+	return strings.Join(parts, ":")
+}
+
+func newUpsertGroupFromLazy(engine *Engine, value []interface{}) *upsertGroup {
+	schema := engine.registry.GetTableSchema(value[0].(string)).(*tableSchema)
+	group := &upsertGroup{schema: schema, index: value[1].(string)}
+	fields, _ := value[2].([]interface{})
+	group.fields = make([]string, len(fields))
+	for i, field := range fields {
+		group.fields[i] = field.(string)
+	}
+	binds := value[3].([]interface{})
+	group.binds = make([]Bind, len(binds))
+	group.entities = make([]Entity, len(binds))
+	for i, bind := range binds {
+		group.binds[i] = bind.(map[string]interface{})
+		group.entities[i] = reflect.New(schema.t).Interface().(Entity)
+	}
+	return group
+}
@@ -0,0 +1,86 @@
+package orm
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type upsertEntity struct {
+	ORM      `orm:"localCache;redisCache"`
+	ID       uint
+	Name     string `orm:"unique=name;required"`
+	Age      int    `orm:"index=age"`
+	Counter  uint
+	IndexAge *CachedQuery `query:":Age = ?"`
+}
+
+func TestUpsert(t *testing.T) {
+	var entity *upsertEntity
+	engine := PrepareTables(t, &Registry{}, 5, entity)
+
+	engine.Flush(&upsertEntity{Name: "John", Age: 18, Counter: 1})
+
+	flusher := engine.NewFlusher()
+	assert.PanicsWithError(t, "unknown unique index invalid in orm.upsertEntity", func() {
+		flusher.Upsert(&upsertEntity{Name: "John"}, "invalid")
+	})
+	assert.PanicsWithError(t, "invalid upsert field Invalid in orm.upsertEntity", func() {
+		flusher.Upsert(&upsertEntity{Name: "John"}, "name", "Invalid")
+	})
+
+	var rows []*upsertEntity
+	total := engine.CachedSearch(&rows, "IndexAge", nil, 18)
+	assert.Equal(t, 1, total)
+
+	john := &upsertEntity{Name: "John", Age: 20, Counter: 10}
+	tom := &upsertEntity{Name: "Tom", Age: 30, Counter: 5}
+	flusher.Upsert(john, "name", "Age").Upsert(tom, "name", "Age").Flush()
+	assert.Equal(t, uint(1), john.ID)
+	assert.Equal(t, 20, john.Age)
+	assert.Equal(t, uint(1), john.Counter)
+	assert.True(t, john.Loaded())
+	assert.Greater(t, tom.ID, uint(1))
+	assert.Equal(t, 30, tom.Age)
+
+	entity = &upsertEntity{}
+	assert.True(t, engine.LoadByID(1, entity))
+	assert.Equal(t, 20, entity.Age)
+	assert.Equal(t, uint(1), entity.Counter)
+	entity = &upsertEntity{}
+	assert.True(t, engine.LoadByID(uint64(tom.ID), entity))
+	assert.Equal(t, "Tom", entity.Name)
+
+	total = engine.CachedSearch(&rows, "IndexAge", nil, 18)
+	assert.Equal(t, 0, total)
+	total = engine.CachedSearch(&rows, "IndexAge", nil, 20)
+	assert.Equal(t, 1, total)
+
+	john = &upsertEntity{Name: "John", Age: 40}
+	engine.NewFlusher().Upsert(john, "name").Flush()
+	assert.Equal(t, uint(1), john.ID)
+	assert.Equal(t, 20, john.Age)
+
+	receiver := NewAsyncConsumer(engine, "default-consumer")
+	receiver.DisableLoop()
+	receiver.block = time.Millisecond
+
+	john = &upsertEntity{Name: "John", Age: 50}
+	adam := &upsertEntity{Name: "Adam", Age: 50}
+	engine.NewFlusher().Upsert(john, "name", "Age").Upsert(adam, "name", "Age").FlushLazy()
+	entity = &upsertEntity{}
+	assert.True(t, engine.LoadByID(1, entity))
+	assert.Equal(t, 20, entity.Age)
+	receiver.Digest(context.Background(), 100)
+
+	entity = &upsertEntity{}
+	assert.True(t, engine.LoadByID(1, entity))
+	assert.Equal(t, 50, entity.Age)
+	entity = &upsertEntity{}
+	assert.True(t, engine.SearchOne(NewWhere("`Name` = ?", "Adam"), entity))
+	assert.Equal(t, 50, entity.Age)
+	total = engine.CachedSearch(&rows, "IndexAge", nil, 50)
+	assert.Equal(t, 2, total)
+}
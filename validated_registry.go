@@ -18,22 +18,28 @@ type ValidatedRegistry interface {
 	GetRedisPools() []string
 	GetRedisSearchIndices() map[string][]*RedisSearchIndex
 	GetEntities() map[string]reflect.Type
+	Close()
 }
 
 type validatedRegistry struct {
-	registry             *Registry
-	tableSchemas         map[reflect.Type]*tableSchema
-	entities             map[string]reflect.Type
-	redisSearchIndexes   map[string]map[string]*RedisSearchIndex
-	sqlClients           map[string]*DBConfig
-	clickHouseClients    map[string]*ClickHouseConfig
-	localCacheContainers map[string]*LocalCacheConfig
-	redisServers         map[string]*RedisCacheConfig
-	redisStreamGroups    map[string]map[string]map[string]bool
-	redisStreamPools     map[string]string
-	elasticServers       map[string]*ElasticConfig
-	lockServers          map[string]string
-	enums                map[string]Enum
+	registry              *Registry
+	tableSchemas          map[reflect.Type]*tableSchema
+	entities              map[string]reflect.Type
+	redisSearchIndexes    map[string]map[string]*RedisSearchIndex
+	sqlClients            map[string]*DBConfig
+	clickHouseClients     map[string]*ClickHouseConfig
+	localCacheContainers  map[string]*LocalCacheConfig
+	redisServers          map[string]*RedisCacheConfig
+	redisStreamGroups     map[string]map[string]map[string]bool
+	redisStreamPools      map[string]string
+	elasticServers        map[string]*ElasticConfig
+	lockServers           map[string]string
+	enums                 map[string]Enum
+	localCacheInvalidator *localCacheInvalidator
+	stampedeProtection    *stampedeProtection
+	cacheMetrics          *cacheMetrics
+	redisCodecs           map[byte]RedisCodec
+	redisScripts          *redisScripts
 }
 
 func (r *validatedRegistry) GetSourceRegistry() *Registry {
@@ -89,9 +95,18 @@ func (r *validatedRegistry) GetRedisPools() []string {
 }
 
 func (r *validatedRegistry) CreateEngine() *Engine {
+	if r.localCacheInvalidator != nil {
+		r.localCacheInvalidator.start()
+	}
 	return &Engine{registry: r, context: context.Background()}
 }
 
+func (r *validatedRegistry) Close() {
+	if r.localCacheInvalidator != nil {
+		r.localCacheInvalidator.stop()
+	}
+}
+
 func (r *validatedRegistry) GetTableSchema(entityName string) TableSchema {
 	t, has := r.entities[entityName]
 	if !has {
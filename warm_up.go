@@ -0,0 +1,103 @@
+package orm
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+const defaultWarmUpBatchSize = 1000
+
+type WarmUpOptions struct {
+	Pause    time.Duration
+	Progress func(loaded int)
+}
+
+func firstWarmUpOptions(options []*WarmUpOptions) *WarmUpOptions {
+	if len(options) > 0 {
+		return options[0]
+	}
+	return nil
+}
+
+func warmUpCache(engine *Engine, entity Entity, where *Where, batchSize int, options *WarmUpOptions) (loaded int) {
+	schema := initIfNeeded(engine, entity).tableSchema
+	localCache, hasLocalCache := schema.GetLocalCache(engine)
+	redisCache, hasRedis := schema.GetRedisCache(engine)
+	if !hasLocalCache && !hasRedis {
+		panic(fmt.Errorf("cache warm up not allowed for entity without cache: '%s'", schema.t.String()))
+	}
+	if batchSize <= 0 {
+		batchSize = defaultWarmUpBatchSize
+	}
+	if where == nil {
+		where = NewWhere("1")
+	}
+	lastID := uint64(0)
+	for {
+		parameters := append([]interface{}{lastID}, where.GetParameters()...)
+		/* #nosec */
+		query := "SELECT " + schema.fieldsQuery + " FROM `" + schema.tableName + "` WHERE `ID` > ? AND (" + where.String() +
+			") ORDER BY `ID` LIMIT " + strconv.Itoa(batchSize)
+		results, def := schema.GetMysql(engine).Query(query, parameters...)
+		localPairs := make([]interface{}, 0)
+		redisPairs := make([]interface{}, 0)
+		rows := 0
+		for results.Next() {
+			pointers := prepareScan(schema)
+			results.Scan(pointers...)
+			convertScan(schema.fields, 0, pointers)
+			lastID = pointers[0].(uint64)
+			cacheKey := schema.getCacheKey(lastID)
+			if hasLocalCache {
+				localPairs = append(localPairs, cacheKey, pointers)
+			}
+			if hasRedis {
+				redisPairs = append(redisPairs, cacheKey, encodeRedisRow(schema.redisCodec, pointers))
+			}
+			rows++
+		}
+		def()
+		if rows == 0 {
+			return loaded
+		}
+		if hasLocalCache {
+			localCache.MSet(localPairs...)
+		}
+		if hasRedis {
+			redisCache.mSetWithTTL(schema.redisSetTTL(), redisPairs...)
+		}
+		loaded += rows
+		if options != nil && options.Progress != nil {
+			options.Progress(loaded)
+		}
+		if rows < batchSize {
+			return loaded
+		}
+		if options != nil && options.Pause > 0 {
+			time.Sleep(options.Pause)
+		}
+	}
+}
+
+func warmUpCachedSearch(engine *Engine, entity Entity, indexName string, arguments []interface{}, batchSize int,
+	options *WarmUpOptions) (totalRows int) {
+	totalRows, ids := cachedSearch(engine, entity, indexName, nil, arguments, nil)
+	if batchSize <= 0 {
+		batchSize = defaultWarmUpBatchSize
+	}
+	for start := 0; start < len(ids); start += batchSize {
+		end := start + batchSize
+		if end > len(ids) {
+			end = len(ids)
+		}
+		warmUpCache(engine, entity, NewWhere("`ID` IN ?", ids[start:end]), batchSize, nil)
+		if options != nil && options.Progress != nil {
+			options.Progress(end)
+		}
+		if end < len(ids) && options != nil && options.Pause > 0 {
+			time.Sleep(options.Pause)
+		}
+	}
+	return totalRows
+}
@@ -0,0 +1,75 @@
+package orm
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type warmUpEntity struct {
+	ORM       `orm:"localCache;redisCache"`
+	ID        uint
+	Name      string
+	Age       int
+	IndexAge  *CachedQuery `query:":Age = ? ORDER BY :ID"`
+	IndexName *CachedQuery `query:":Name = ?"`
+}
+
+type warmUpNoCacheEntity struct {
+	ORM
+	ID uint
+}
+
+func TestWarmUpCache(t *testing.T) {
+	var entity *warmUpEntity
+	engine := PrepareTables(t, &Registry{}, 5, entity, &warmUpNoCacheEntity{})
+	for i := 0; i < 10; i++ {
+		engine.Flush(&warmUpEntity{Name: "name", Age: i % 2})
+	}
+	schema := engine.GetRegistry().GetTableSchemaForEntity(entity).(*tableSchema)
+	engine.GetLocalCache().Clear()
+	engine.GetRedis().FlushDB()
+
+	progress := make([]int, 0)
+	loaded := engine.WarmUpCache(&warmUpEntity{}, NewWhere("`Age` = ?", 1), 2, &WarmUpOptions{Progress: func(loaded int) {
+		progress = append(progress, loaded)
+	}})
+	assert.Equal(t, 5, loaded)
+	assert.Equal(t, []int{2, 4, 5}, progress)
+	assert.Equal(t, 5, engine.GetLocalCache().GetObjectsCount())
+	assert.Equal(t, int64(1), engine.GetRedis().Exists(schema.getCacheKey(2)))
+	assert.Equal(t, int64(0), engine.GetRedis().Exists(schema.getCacheKey(1)))
+
+	engine.ResetCacheMetrics()
+	entity = &warmUpEntity{}
+	assert.True(t, engine.LoadByID(4, entity))
+	assert.Equal(t, 1, entity.Age)
+	engine.GetLocalCache().Clear()
+	assert.True(t, engine.LoadByID(6, entity))
+	for _, metric := range engine.GetCacheMetrics() {
+		assert.NotEqual(t, CacheLayerDB, metric.Layer)
+	}
+
+	engine.GetLocalCache().Clear()
+	engine.GetRedis().FlushDB()
+	loaded = engine.WarmUpCache(&warmUpEntity{}, nil, 0)
+	assert.Equal(t, 10, loaded)
+	assert.Equal(t, 10, engine.GetLocalCache().GetObjectsCount())
+
+	engine.GetLocalCache().Clear()
+	engine.GetRedis().FlushDB()
+	totalRows := engine.WarmUpCachedSearch(&warmUpEntity{}, "IndexAge", []interface{}{0}, 3)
+	assert.Equal(t, 5, totalRows)
+	engine.ResetCacheMetrics()
+	var rows []*warmUpEntity
+	totalRows = engine.CachedSearch(&rows, "IndexAge", NewPager(1, 10), 0)
+	assert.Equal(t, 5, totalRows)
+	assert.Len(t, rows, 5)
+	for _, metric := range engine.GetCacheMetrics() {
+		assert.NotEqual(t, CacheLayerDB, metric.Layer)
+	}
+
+	assert.PanicsWithError(t, "cache warm up not allowed for entity without cache: 'orm.warmUpNoCacheEntity'", func() {
+		engine.WarmUpCache(&warmUpNoCacheEntity{}, nil, 10)
+	})
+}
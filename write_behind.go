@@ -0,0 +1,290 @@
+package orm
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+)
+
+const writeBehindLockKey = "orm-write-behind"
+const writeBehindLockTTL = time.Second * 90
+const defaultWriteBehindBatchSize = 1000
+const redisSetNX = time.Duration(-1)
+
+const writeBehindSetScript = `redis.call('SET', KEYS[1], ARGV[1])
+redis.call('SADD', KEYS[2], ARGV[2])
+if ARGV[3] == '' then
+	redis.call('HDEL', KEYS[3], ARGV[2])
+else
+	redis.call('HSET', KEYS[3], ARGV[2], ARGV[3])
+end
+return 1`
+
+const writeBehindMetaDeleteScript = `for i = 1, #ARGV, 2 do
+	if redis.call('HGET', KEYS[1], ARGV[i]) == ARGV[i + 1] then
+		redis.call('HDEL', KEYS[1], ARGV[i])
+	end
+end
+return 1`
+
+const writeBehindPopScript = `local ids = redis.call('SMEMBERS', KEYS[2])
+if #ids == 0 then
+	ids = redis.call('SPOP', KEYS[1], ARGV[1])
+	if #ids > 0 then
+		redis.call('SADD', KEYS[2], unpack(ids))
+	end
+end
+return ids`
+
+type WriteBehindPersister struct {
+	engine       *Engine
+	interval     time.Duration
+	batchSize    int
+	disableLoop  bool
+	errorHandler func(err interface{})
+}
+
+func NewWriteBehindPersister(engine *Engine) *WriteBehindPersister {
+	return &WriteBehindPersister{engine: engine, interval: time.Second, batchSize: defaultWriteBehindBatchSize}
+}
+
+func (p *WriteBehindPersister) DisableLoop() {
+	p.disableLoop = true
+}
+
+func (p *WriteBehindPersister) SetInterval(interval time.Duration) {
+	p.interval = interval
+}
+
+func (p *WriteBehindPersister) SetBatchSize(size int) {
+	p.batchSize = size
+}
+
+func (p *WriteBehindPersister) RegisterErrorHandler(handler func(err interface{})) {
+	p.errorHandler = handler
+}
+
+func (p *WriteBehindPersister) Digest(ctx context.Context) {
+	var lock *Lock
+	defer func() {
+		if lock != nil {
+			lock.Release()
+		}
+	}()
+	for {
+		if lock == nil {
+			lock, _ = p.engine.GetLocker().Obtain(ctx, writeBehindLockKey, writeBehindLockTTL, 0)
+		} else if !lock.Refresh(ctx, writeBehindLockTTL) {
+			lock = nil
+		}
+		if lock != nil {
+			p.persistAll()
+		}
+		if p.disableLoop {
+			return
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(p.interval):
+		}
+	}
+}
+
+func (p *WriteBehindPersister) persistAll() {
+	for _, schema := range p.engine.registry.tableSchemas {
+		if !schema.writeBehind {
+			continue
+		}
+		func() {
+			defer func() {
+				if rec := recover(); rec != nil {
+					if p.errorHandler == nil {
+						panic(rec)
+					}
+					p.errorHandler(rec)
+				}
+			}()
+			for {
+				if persistWriteBehind(p.engine, schema, p.batchSize) < p.batchSize {
+					return
+				}
+			}
+		}()
+	}
+}
+
+func persistWriteBehind(engine *Engine, schema *tableSchema, batchSize int) int {
+	redisCache, _ := schema.GetRedisCache(engine)
+	dirtyKey := getWriteBehindDirtyKey(schema)
+	processingKey := dirtyKey + ":processing"
+	ids := redisCache.Eval(writeBehindPopScript, []string{dirtyKey, processingKey}, batchSize).([]interface{})
+	if len(ids) == 0 {
+		return 0
+	}
+	cacheKeys := make([]string, len(ids))
+	idsMapping := make(map[string]uint64, len(ids))
+	for i, value := range ids {
+		id, _ := strconv.ParseUint(value.(string), 10, 64)
+		cacheKeys[i] = schema.getCacheKey(id)
+		idsMapping[cacheKeys[i]] = id
+	}
+	rows := redisCache.MGet(cacheKeys...)
+	var metas map[string]interface{}
+	if schema.hasLog {
+		fields := make([]string, len(ids))
+		for i, id := range ids {
+			fields[i] = id.(string)
+		}
+		metas = redisCache.HMget(getWriteBehindMetaKey(schema), fields...)
+	}
+	columns := make([]string, len(schema.columnNames)-1)
+	for i, column := range schema.columnNames[1:] {
+		columns[i] = "`" + column + "` = ?"
+	}
+	/* #nosec */
+	sql := "UPDATE `" + schema.tableName + "` SET " + strings.Join(columns, ",") + " WHERE `ID` = ?"
+	db := schema.GetMysql(engine)
+	db.Begin()
+	defer db.Rollback()
+	before := loadWriteBehindRows(db, schema, idsMapping)
+	changes := make(map[uint64]map[string]interface{})
+	after := make(map[uint64][]interface{})
+	for _, cacheKey := range cacheKeys {
+		value, has := rows[cacheKey].(string)
+		if !has || value == "nil" {
+			continue
+		}
+		id := idsMapping[cacheKey]
+		old, has := before[id]
+		if !has {
+			continue
+		}
+		row := decodeRedisRow(engine.registry, schema, value)
+		bind := make(map[string]interface{})
+		for _, column := range schema.columnNames[1:] {
+			index := schema.columnMapping[column]
+			if !reflect.DeepEqual(old[index], row[index]) {
+				bind[column] = row[index]
+			}
+		}
+		if len(bind) == 0 {
+			continue
+		}
+		db.Exec(sql, append(row[1:], id)...)
+		changes[id] = bind
+		after[id] = row
+	}
+	db.Commit()
+	afterWriteBehindPersisted(engine, schema, before, after, changes, metas)
+	if len(metas) > 0 {
+		arguments := make([]interface{}, 0, len(metas)*2)
+		for id, meta := range metas {
+			if meta != nil {
+				arguments = append(arguments, id, meta)
+			}
+		}
+		if len(arguments) > 0 {
+			redisCache.Eval(writeBehindMetaDeleteScript, []string{getWriteBehindMetaKey(schema)}, arguments...)
+		}
+	}
+	redisCache.Del(processingKey)
+	return len(ids)
+}
+
+func loadWriteBehindRows(db *DB, schema *tableSchema, ids map[string]uint64) map[uint64][]interface{} {
+	q := make([]string, 0, len(ids))
+	for _, id := range ids {
+		q = append(q, strconv.FormatUint(id, 10))
+	}
+	/* #nosec */
+	query := "SELECT " + schema.fieldsQuery + " FROM `" + schema.tableName + "` WHERE `ID` IN (" + strings.Join(q, ",") + ") FOR UPDATE"
+	results, def := db.Query(query)
+	defer def()
+	rows := make(map[uint64][]interface{}, len(ids))
+	for results.Next() {
+		pointers := prepareScan(schema)
+		results.Scan(pointers...)
+		convertScan(schema.fields, 0, pointers)
+		rows[pointers[0].(uint64)] = pointers
+	}
+	def()
+	return rows
+}
+
+func afterWriteBehindPersisted(engine *Engine, schema *tableSchema, before, after map[uint64][]interface{},
+	changes map[uint64]map[string]interface{}, metas map[string]interface{}) {
+	if len(changes) == 0 {
+		return
+	}
+	redisCache, _ := schema.GetRedisCache(engine)
+	localCache, hasLocalCache := schema.GetLocalCache(engine)
+	rFlusher := &redisFlusher{engine: engine}
+	localKeys := make([]string, 0)
+	for id, bind := range changes {
+		keys := getCacheQueriesKeys(schema, bind, after[id], false)
+		keys = append(keys, getCacheQueriesKeys(schema, bind, before[id], false)...)
+		keys = append(keys, getAdHocSearchTagsKeys(schema, bind, false)...)
+		rFlusher.Del(redisCache.code, keys...)
+		localKeys = append(localKeys, keys...)
+		addCachedAggregatesDeltas(schema, rFlusher, bind, before[id], after[id])
+		addDirtyQueues(rFlusher, bind, schema, id, "u")
+		var meta map[string]interface{}
+		encoded, has := metas[strconv.FormatUint(id, 10)].(string)
+		if has {
+			_ = jsoniter.ConfigFastest.UnmarshalFromString(encoded, &meta)
+		}
+		addToLogQueue(engine, rFlusher, schema, id, convertDBDataToMap(schema, before[id]), bind, meta)
+	}
+	rFlusher.Flush()
+	if hasLocalCache && len(localKeys) > 0 {
+		localCache.Remove(localKeys...)
+		publishLocalCacheInvalidation(engine, localCache.code, localKeys...)
+	}
+}
+
+func addWriteBehind(engine *Engine, schema *tableSchema, redisFlusher RedisFlusher, id uint64, entity Entity) {
+	keys := []string{schema.getCacheKey(id), getWriteBehindDirtyKey(schema), getWriteBehindMetaKey(schema)}
+	meta := ""
+	if schema.hasLog {
+		merged := make(map[string]interface{})
+		for k, v := range entity.getORM().logMeta {
+			merged[k] = v
+		}
+		for k, v := range engine.logMetaData {
+			merged[k] = v
+		}
+		if len(merged) > 0 {
+			meta, _ = jsoniter.ConfigFastest.MarshalToString(merged)
+		}
+	}
+	redisFlusher.eval(schema.redisCacheName, writeBehindSetScript, keys, buildRedisValue(entity), id, meta)
+}
+
+func getWriteBehindDirtyKey(schema *tableSchema) string {
+	return schema.cachePrefix + ":writeBehind"
+}
+
+func getWriteBehindMetaKey(schema *tableSchema) string {
+	return schema.cachePrefix + ":writeBehind:meta"
+}
+
+func parseWriteBehind(tags map[string]map[string]string, redisCache string, entityType reflect.Type) (bool, error) {
+	_, has := tags["ORM"]["writeBehind"]
+	if !has {
+		return false, nil
+	}
+	if redisCache == "" {
+		return false, fmt.Errorf("writeBehind in %s requires redis cache", entityType.String())
+	}
+	_, has = tags["ORM"]["redisCacheTTL"]
+	if has {
+		return false, fmt.Errorf("writeBehind in %s can't be used with redisCacheTTL", entityType.String())
+	}
+	return true, nil
+}
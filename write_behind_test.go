@@ -0,0 +1,125 @@
+package orm
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type writeBehindEntity struct {
+	ORM         `orm:"localCache;redisCache;writeBehind"`
+	ID          uint
+	Name        string       `orm:"index=Name"`
+	Counter     uint         `orm:"dirty=write_behind_changed"`
+	CountByName *CachedQuery `cachedCount:":Name = ?"`
+}
+
+type writeBehindLogEntity struct {
+	ORM  `orm:"localCache;redisCache;writeBehind;log"`
+	ID   uint
+	Name string
+}
+
+type writeBehindInvalidEntity struct {
+	ORM `orm:"localCache;writeBehind"`
+	ID  uint
+}
+
+func TestWriteBehind(t *testing.T) {
+	var entity *writeBehindEntity
+	registry := &Registry{}
+	registry.RegisterRedisStream("write_behind_changed", "default", []string{"test-group"})
+	engine := PrepareTables(t, registry, 5, entity)
+	schema := engine.GetRegistry().GetTableSchemaForEntity(entity).(*tableSchema)
+	engine.FlushMany(&writeBehindEntity{Name: "a"}, &writeBehindEntity{Name: "b"})
+	assert.Equal(t, int64(2), engine.GetRedis().XLen("write_behind_changed"))
+	assert.Equal(t, 1, engine.CachedCount(&writeBehindEntity{}, "CountByName", "b"))
+	assert.Equal(t, 0, engine.CachedCount(&writeBehindEntity{}, "CountByName", "c"))
+
+	entity = &writeBehindEntity{}
+	engine.LoadByID(1, entity)
+	for i := 0; i < 10; i++ {
+		entity.Counter++
+		engine.Flush(entity)
+	}
+	engine.UpdateFields(entity, 2, Bind{"Name": "c"})
+
+	counter := 0
+	engine.GetMysql().QueryRow(NewWhere("SELECT `Counter` FROM `writeBehindEntity` WHERE `ID` = 1"), &counter)
+	assert.Equal(t, 0, counter)
+	assert.Equal(t, int64(2), engine.GetRedis().SCard(getWriteBehindDirtyKey(schema)))
+	assert.Equal(t, int64(2), engine.GetRedis().XLen("write_behind_changed"))
+	assert.Equal(t, 1, engine.CachedCount(&writeBehindEntity{}, "CountByName", "b"))
+	assert.Equal(t, 0, engine.CachedCount(&writeBehindEntity{}, "CountByName", "c"))
+
+	pending, _ := engine.GetRedis().Get(schema.getCacheKey(1))
+	engine.GetRedis().mSetWithTTL(schema.redisSetTTL(), schema.getCacheKey(1), "stale")
+	stored, _ := engine.GetRedis().Get(schema.getCacheKey(1))
+	assert.Equal(t, pending, stored)
+	engine.GetLocalCache().Clear()
+	entity = &writeBehindEntity{}
+	assert.True(t, engine.LoadByID(1, entity))
+	assert.Equal(t, uint(10), entity.Counter)
+
+	persister := NewWriteBehindPersister(engine)
+	persister.DisableLoop()
+	persister.SetBatchSize(1)
+	persister.Digest(context.Background())
+	engine.GetMysql().QueryRow(NewWhere("SELECT `Counter` FROM `writeBehindEntity` WHERE `ID` = 1"), &counter)
+	assert.Equal(t, 10, counter)
+	name := ""
+	engine.GetMysql().QueryRow(NewWhere("SELECT `Name` FROM `writeBehindEntity` WHERE `ID` = 2"), &name)
+	assert.Equal(t, "c", name)
+	assert.Equal(t, int64(0), engine.GetRedis().SCard(getWriteBehindDirtyKey(schema)))
+	assert.Equal(t, int64(3), engine.GetRedis().XLen("write_behind_changed"))
+	assert.Equal(t, 0, engine.CachedCount(&writeBehindEntity{}, "CountByName", "b"))
+	assert.Equal(t, 1, engine.CachedCount(&writeBehindEntity{}, "CountByName", "c"))
+
+	entity.Counter = 20
+	engine.Flush(entity)
+	engine.Delete(entity)
+	persister.Digest(context.Background())
+	found := engine.GetMysql().QueryRow(NewWhere("SELECT `Counter` FROM `writeBehindEntity` WHERE `ID` = 1"), &counter)
+	assert.False(t, found)
+
+	registry = &Registry{}
+	registry.RegisterMySQLPool("root:root@tcp(localhost:3311)/test")
+	registry.RegisterLocalCache(100)
+	registry.RegisterEntity(&writeBehindInvalidEntity{})
+	_, err := registry.Validate()
+	assert.EqualError(t, err, "writeBehind in orm.writeBehindInvalidEntity requires redis cache")
+}
+
+func TestWriteBehindLogMeta(t *testing.T) {
+	var entity *writeBehindLogEntity
+	engine := PrepareTables(t, &Registry{}, 5, entity)
+	engine.GetMysql().Exec("TRUNCATE TABLE `_log_default_writeBehindLogEntity`")
+	schema := engine.GetRegistry().GetTableSchemaForEntity(entity).(*tableSchema)
+	engine.Flush(&writeBehindLogEntity{Name: "a"})
+
+	entity = &writeBehindLogEntity{}
+	engine.LoadByID(1, entity)
+	entity.Name = "b"
+	entity.SetEntityLogMeta("admin_id", "10")
+	engine.Flush(entity)
+	assert.Equal(t, int64(1), engine.GetRedis().HLen(getWriteBehindMetaKey(schema)))
+
+	persister := NewWriteBehindPersister(engine)
+	persister.DisableLoop()
+	persister.Digest(context.Background())
+	assert.Equal(t, int64(0), engine.GetRedis().HLen(getWriteBehindMetaKey(schema)))
+
+	consumer := NewAsyncConsumer(engine, "default-consumer")
+	consumer.DisableLoop()
+	consumer.block = time.Millisecond
+	consumer.Digest(context.Background(), 100)
+	var meta sql.NullString
+	var changes string
+	where := NewWhere("SELECT `meta`, `changes` FROM `_log_default_writeBehindLogEntity` WHERE `ID` = 2")
+	assert.True(t, engine.GetMysql().QueryRow(where, &meta, &changes))
+	assert.Equal(t, "{\"Name\": \"b\"}", changes)
+	assert.Equal(t, "{\"admin_id\": \"10\"}", meta.String)
+}
@@ -24,6 +24,11 @@ func InitByYaml(yaml map[string]interface{}) (registry *Registry) {
 				validateRedisURI(registry, value, key)
 			case "sentinel":
 				validateSentinel(registry, value, key)
+			case "redis_cluster":
+				validateRedisCluster(registry, value, key)
+			case "redis_prefix":
+				valAsString := validateOrmString(value, key)
+				registry.SetRedisPrefix(valAsString, key)
 			case "streams":
 				validateStreams(registry, value, key)
 			case "locker":
@@ -33,8 +38,13 @@ func InitByYaml(yaml map[string]interface{}) (registry *Registry) {
 				valAsString := validateOrmString(value, key)
 				registry.SetDefaultEncoding(valAsString)
 			case "local_cache":
-				number := validateOrmInt(value, key)
-				registry.RegisterLocalCache(number, key)
+				validateLocalCache(registry, value, key)
+			case "local_cache_invalidation":
+				valAsString := validateOrmString(value, key)
+				registry.RegisterLocalCacheInvalidation(valAsString)
+			case "cache_stampede_locker":
+				valAsString := validateOrmString(value, key)
+				registry.RegisterCacheStampedeLocker(valAsString)
 			}
 		}
 	}
@@ -126,6 +136,40 @@ func validateSentinel(registry *Registry, value interface{}, key string) {
 	}
 }
 
+func validateRedisCluster(registry *Registry, value interface{}, key string) {
+	asSlice, ok := value.([]interface{})
+	if !ok || len(asSlice) == 0 {
+		panic(fmt.Errorf("redis cluster '%v' is not valid", value))
+	}
+	addresses := make([]string, len(asSlice))
+	for i, v := range asSlice {
+		addresses[i] = fmt.Sprintf("%v", v)
+	}
+	registry.RegisterRedisCluster(addresses, key)
+}
+
+func validateLocalCache(registry *Registry, value interface{}, key string) {
+	number, ok := value.(int)
+	if ok {
+		registry.RegisterLocalCache(number, key)
+		return
+	}
+	options := LocalCacheOptions{}
+	for option, optionValue := range fixYamlMap(value, key) {
+		switch option {
+		case "size":
+			options.Size = validateOrmInt(optionValue, key)
+		case "shards":
+			options.Shards = validateOrmInt(optionValue, key)
+		case "max_bytes":
+			options.MaxBytes = int64(validateOrmInt(optionValue, key))
+		default:
+			panic(fmt.Errorf("local cache option '%s' is not valid", option))
+		}
+	}
+	registry.RegisterLocalCacheWithOptions(options, key)
+}
+
 func fixYamlMap(value interface{}, key string) map[string]interface{} {
 	def, ok := value.(map[string]interface{})
 	if !ok {
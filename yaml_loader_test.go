@@ -19,6 +19,14 @@ func TestYamlLoader(t *testing.T) {
 	assert.NotNil(t, registry)
 	assert.Len(t, registry.redisStreamGroups, 2)
 	assert.NotNil(t, registry.redisServers["another"])
+	assert.True(t, registry.redisServers["cluster"].cluster)
+	assert.Equal(t, "queue:", registry.redisPrefixes["default_queue"])
+	assert.False(t, registry.redisServers["default"].cluster)
+	assert.Equal(t, "default", registry.localCacheInvalidationPool)
+	assert.Equal(t, "default", registry.cacheStampedeLocker)
+	assert.Len(t, registry.localCacheContainers["default"].shards, 1)
+	assert.Len(t, registry.localCacheContainers["another"].shards, 4)
+	assert.Equal(t, int64(262144), registry.localCacheContainers["another"].shards[0].maxBytes)
 	assert.Len(t, registry.redisStreamGroups["default"], 2)
 	assert.Len(t, registry.redisStreamGroups["another"], 1)
 	assert.Len(t, registry.redisStreamGroups["default"]["stream-1"], 2)
@@ -50,6 +58,12 @@ func TestYamlLoader(t *testing.T) {
 		registry = InitByYaml(invalidYaml)
 	})
 
+	invalidYaml = make(map[string]interface{})
+	invalidYaml["default"] = map[string]interface{}{"local_cache": map[string]interface{}{"limit": 10}}
+	assert.PanicsWithError(t, "local cache option 'limit' is not valid", func() {
+		registry = InitByYaml(invalidYaml)
+	})
+
 	invalidYaml = make(map[string]interface{})
 	invalidYaml["default"] = map[string]interface{}{"redis": "invalid"}
 	assert.PanicsWithError(t, "redis uri 'invalid' is not valid", func() {
@@ -68,6 +82,12 @@ func TestYamlLoader(t *testing.T) {
 		registry = InitByYaml(invalidYaml)
 	})
 
+	invalidYaml = make(map[string]interface{})
+	invalidYaml["default"] = map[string]interface{}{"redis_cluster": "localhost:7001"}
+	assert.PanicsWithError(t, "redis cluster 'localhost:7001' is not valid", func() {
+		registry = InitByYaml(invalidYaml)
+	})
+
 	invalidYaml = make(map[string]interface{})
 	invalidYaml["default"] = map[string]interface{}{"local_cache": "test"}
 	assert.PanicsWithError(t, "orm value for default: test is not valid", func() {